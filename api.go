@@ -1,6 +1,8 @@
 package gormx
 
 import (
+	"time"
+
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
@@ -13,10 +15,39 @@ func Query(where any) clause.Expression {
 	return expression
 }
 
+// Order orders by the relevance of the fulltext fields of where tagged
+// order:relevance, it is used as db.Where(Query(where)).Clauses(Order(where)).
+func Order(where any) clause.Expression {
+	return orderModifyStatement{where}
+}
+
 func Update(update any) gorm.StatementModifier {
 	return &updateModifyStatement{update}
 }
 
+// RegisterQueryExpr registers a query_expr operator, aliases are other names
+// the operator can be tagged with. Registering an existing name replaces it.
+func RegisterQueryExpr(name string, builder QueryExprBuilder, aliases ...string) error {
+	return registerQueryExpr(name, builder, aliases)
+}
+
+// RegisterUpdateExpr registers an update_expr operator, aliases are other names
+// the operator can be tagged with. Registering an existing name replaces it.
+func RegisterUpdateExpr(name string, builder UpdateExprBuilder, aliases ...string) error {
+	return registerUpdateExpr(name, builder, aliases)
+}
+
+// SetNowFunc sets the clock of the within query_expr, nil restores time.Now.
+func SetNowFunc(fn func() time.Time) {
+	setNowFunc(fn)
+}
+
+// SetLocation sets the time zone of the time query_expr for fields without tz tag,
+// nil uses the location of the value.
+func SetLocation(loc *time.Location) {
+	setLocation(loc)
+}
+
 type updateModifyStatement struct {
 	update any
 }
@@ -31,3 +62,48 @@ func (u updateModifyStatement) ModifyStatement(stmt *gorm.Statement) {
 	}
 	stmt.Dest = m
 }
+
+type orderModifyStatement struct {
+	where any
+}
+
+var _ gorm.StatementModifier = orderModifyStatement{}
+
+// Build is empty, gorm calls ModifyStatement for the expression passed to Clauses
+func (o orderModifyStatement) Build(clause.Builder) {}
+
+func (o orderModifyStatement) ModifyStatement(stmt *gorm.Statement) {
+	expression, err := buildSQLOrder(o.where)
+	if err != nil {
+		_ = stmt.AddError(err)
+		return
+	} else if expression == nil {
+		return
+	}
+
+	// OrderBy.MergeClause drops OrderBy.Expression when Order is called later, so the order
+	// set before and the relevance are kept in AfterNameExpression, the later orders merge
+	// into Expression and are written after them
+	c := stmt.Clauses["ORDER BY"]
+	var exprs []clause.Expression
+	if kept, ok := c.AfterNameExpression.(clause.CommaExpression); ok && c.Builder != nil {
+		exprs = kept.Exprs
+	}
+	if c.Expression != nil {
+		exprs = append(exprs, c.Expression)
+	}
+	c.Name = "ORDER BY"
+	c.AfterNameExpression = clause.CommaExpression{Exprs: append(exprs, expression)}
+	c.Expression = nil
+	c.Builder = buildOrderBy
+	stmt.Clauses["ORDER BY"] = c
+}
+
+func buildOrderBy(c clause.Clause, builder clause.Builder) {
+	builder.WriteString("ORDER BY ")
+	c.AfterNameExpression.Build(builder)
+	if orderBy, ok := c.Expression.(clause.OrderBy); ok && (orderBy.Expression != nil || len(orderBy.Columns) > 0) {
+		builder.WriteString(", ")
+		orderBy.Build(builder)
+	}
+}
@@ -1,13 +1,396 @@
 package gormx
 
-import "gorm.io/gorm/clause"
+import (
+	"fmt"
 
-type notIn struct {
-	in clause.IN
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// inSubquery is Column IN (Subquery), Subquery is a *gorm.DB or selectSubquery
+type inSubquery struct {
+	Column   interface{}
+	Subquery interface{}
+	Not      bool
+}
+
+func (in inSubquery) Build(builder clause.Builder) {
+	builder.WriteQuoted(in.Column)
+	if in.Not {
+		builder.WriteString(" NOT")
+	}
+	builder.WriteString(" IN (")
+	builder.AddVar(builder, in.Subquery)
+	builder.WriteByte(')')
+}
+
+func (in inSubquery) NegationBuild(builder clause.Builder) {
+	in.Not = !in.Not
+	in.Build(builder)
+}
+
+type exists struct {
+	Subquery interface{}
+	Not      bool
+}
+
+func (e exists) Build(builder clause.Builder) {
+	if e.Not {
+		builder.WriteString("NOT ")
+	}
+	builder.WriteString("EXISTS (")
+	builder.AddVar(builder, e.Subquery)
+	builder.WriteByte(')')
+}
+
+func (e exists) NegationBuild(builder clause.Builder) {
+	e.Not = !e.Not
+	e.Build(builder)
+}
+
+// selectSubquery is SELECT Select FROM Table WHERE Where, Where is built from a nested struct
+type selectSubquery struct {
+	Table  string
+	Select string
+	Where  clause.Expression
+}
+
+func (s selectSubquery) Build(builder clause.Builder) {
+	builder.WriteString("SELECT ")
+	builder.WriteQuoted(clause.Column{Name: s.Select})
+	builder.WriteString(" FROM ")
+	builder.WriteQuoted(clause.Table{Name: s.Table})
+	if s.Where != nil {
+		builder.WriteString(" WHERE ")
+		clause.Where{Exprs: []clause.Expression{s.Where}}.Build(builder)
+	}
+}
+
+// associationSubquery selects the rows of Association of the statement model which
+// belong to the current row and match Where, Count selects COUNT(*) instead of 1.
+// The table of the association is aliased as Alias, it may be the table of the statement
+type associationSubquery struct {
+	Association string
+	Alias       string
+	Where       clause.Expression
+	Count       bool
+}
+
+func (a associationSubquery) Build(builder clause.Builder) {
+	rel, err := relationshipOf(builder, a.Association)
+	if err != nil {
+		_ = builder.AddError(err)
+		return
+	}
+
+	child := a.Alias
+	conditions := []clause.Expression{}
+	if rel.JoinTable != nil {
+		// many2many, the join table links the current row and the child
+		join := a.Alias + "_join"
+		joins := []clause.Expression{}
+		for _, ref := range rel.References {
+			column := clause.Column{Table: join, Name: ref.ForeignKey.DBName}
+			if ref.OwnPrimaryKey {
+				joins = append(joins, clause.Eq{Column: column, Value: clause.Column{Table: clause.CurrentTable, Name: ref.PrimaryKey.DBName}})
+			} else {
+				joins = append(joins, clause.Eq{Column: column, Value: clause.Column{Table: child, Name: ref.PrimaryKey.DBName}})
+			}
+		}
+		conditions = append(conditions, exists{Subquery: clause.Expr{
+			SQL:  "SELECT 1 FROM ? WHERE ?",
+			Vars: []interface{}{clause.Table{Name: rel.JoinTable.Table, Alias: join}, clause.And(joins...)},
+		}})
+	} else {
+		for _, ref := range rel.References {
+			if ref.PrimaryValue != "" {
+				// polymorphic type
+				conditions = append(conditions, clause.Eq{Column: clause.Column{Table: child, Name: ref.ForeignKey.DBName}, Value: ref.PrimaryValue})
+			} else if ref.OwnPrimaryKey {
+				// has one, has many
+				conditions = append(conditions, clause.Eq{Column: clause.Column{Table: child, Name: ref.ForeignKey.DBName}, Value: clause.Column{Table: clause.CurrentTable, Name: ref.PrimaryKey.DBName}})
+			} else {
+				// belongs to
+				conditions = append(conditions, clause.Eq{Column: clause.Column{Table: child, Name: ref.PrimaryKey.DBName}, Value: clause.Column{Table: clause.CurrentTable, Name: ref.ForeignKey.DBName}})
+			}
+		}
+	}
+	if a.Where != nil {
+		conditions = append(conditions, a.Where)
+	}
+
+	if a.Count {
+		builder.WriteString("SELECT COUNT(*) FROM ")
+	} else {
+		builder.WriteString("SELECT 1 FROM ")
+	}
+	builder.WriteQuoted(clause.Table{Name: rel.FieldSchema.Table, Alias: child})
+	builder.WriteString(" WHERE ")
+	clause.Where{Exprs: conditions}.Build(builder)
+}
+
+func relationshipOf(builder clause.Builder, name string) (*schema.Relationship, error) {
+	stmt, ok := builder.(*gorm.Statement)
+	if !ok || stmt.Schema == nil {
+		return nil, fmt.Errorf("association(%s) need the model of statement", name)
+	}
+	rel, ok := stmt.Schema.Relationships.Relations[name]
+	if !ok {
+		return nil, fmt.Errorf("association(%s) not found in %s", name, stmt.Schema.Name)
+	}
+	return rel, nil
+}
+
+type between struct {
+	Column interface{}
+	From   interface{}
+	To     interface{}
+	Not    bool
+}
+
+func (b between) Build(builder clause.Builder) {
+	builder.WriteQuoted(b.Column)
+	if b.Not {
+		builder.WriteString(" NOT")
+	}
+	builder.WriteString(" BETWEEN ")
+	builder.AddVar(builder, b.From)
+	builder.WriteString(" AND ")
+	builder.AddVar(builder, b.To)
+}
+
+func (b between) NegationBuild(builder clause.Builder) {
+	b.Not = !b.Not
+	b.Build(builder)
+}
+
+// escapedLike matches Value literally, AnyPrefix/AnySuffix add the % wildcards
+type escapedLike struct {
+	Column     interface{}
+	Value      string
+	AnyPrefix  bool
+	AnySuffix  bool
+	Not        bool
+	IgnoreCase bool
+}
+
+func (l escapedLike) Build(builder clause.Builder) {
+	buildWithDialect(builder, func(dialect Dialect) (clause.Expression, error) {
+		pattern, escape := dialect.EscapeLike(l.Value)
+		if l.AnyPrefix {
+			pattern = "%" + pattern
+		}
+		if l.AnySuffix {
+			pattern += "%"
+		}
+		if l.IgnoreCase {
+			return dialect.ILike(l.Column, pattern, escape, l.Not)
+		}
+		if l.Not {
+			return clause.Expr{SQL: "? NOT LIKE ? ESCAPE " + escape, Vars: []interface{}{l.Column, pattern}}, nil
+		}
+		return clause.Expr{SQL: "? LIKE ? ESCAPE " + escape, Vars: []interface{}{l.Column, pattern}}, nil
+	})
+}
+
+func (l escapedLike) NegationBuild(builder clause.Builder) {
+	l.Not = !l.Not
+	l.Build(builder)
+}
+
+// mergeJSON merges the JSON object Patch into Column
+type mergeJSON struct {
+	Column clause.Column
+	Patch  string
+}
+
+func (m mergeJSON) Build(builder clause.Builder) {
+	buildWithDialect(builder, func(dialect Dialect) (clause.Expression, error) {
+		return dialect.MergeJSON(m.Column, m.Patch)
+	})
+}
+
+// jsonPathUpdate changes the json document Doc at Path, Doc is the column or
+// another change of the same column so that several paths can be updated at once.
+type jsonPathUpdate struct {
+	Operator string
+	Doc      interface{}
+	Path     JSONPath
+	Value    string // json text, empty for json_remove
+}
+
+func (u jsonPathUpdate) Build(builder clause.Builder) {
+	buildWithDialect(builder, func(dialect Dialect) (clause.Expression, error) {
+		switch u.Operator {
+		case updateExprJSONSet:
+			return dialect.JSONSet(u.Doc, u.Path, u.Value)
+		case updateExprJSONRemove:
+			return dialect.JSONRemove(u.Doc, u.Path)
+		default:
+			return dialect.JSONArrayAppend(u.Doc, u.Path, u.Value)
+		}
+	})
+}
+
+// jsonExtract is the value at Path of the json document in Column, compared as Type
+type jsonExtract struct {
+	Column clause.Column
+	Path   JSONPath
+	Type   string
+}
+
+func (e jsonExtract) Build(builder clause.Builder) {
+	buildWithDialect(builder, func(dialect Dialect) (clause.Expression, error) {
+		return dialect.JSONExtract(e.Column, e.Path, e.Type)
+	})
+}
+
+// jsonContains reports whether the json document at Path of Column contains the json text Value
+type jsonContains struct {
+	Column clause.Column
+	Path   JSONPath
+	Value  string
+}
+
+func (c jsonContains) Build(builder clause.Builder) {
+	buildWithDialect(builder, func(dialect Dialect) (clause.Expression, error) {
+		return dialect.JSONContains(c.Column, c.Path, c.Value)
+	})
+}
+
+type fullText struct {
+	Columns []clause.Column
+	Value   string
+	Mode    string
+}
+
+func (f fullText) Build(builder clause.Builder) {
+	buildWithDialect(builder, func(dialect Dialect) (clause.Expression, error) {
+		return dialect.FullText(f.Columns, f.Value, f.Mode)
+	})
+}
+
+type regexpMatch struct {
+	Column     interface{}
+	Pattern    string
+	Not        bool
+	IgnoreCase bool
+}
+
+func (r regexpMatch) Build(builder clause.Builder) {
+	buildWithDialect(builder, func(dialect Dialect) (clause.Expression, error) {
+		return dialect.Regexp(r.Column, r.Pattern, r.Not, r.IgnoreCase)
+	})
+}
+
+func (r regexpMatch) NegationBuild(builder clause.Builder) {
+	r.Not = !r.Not
+	r.Build(builder)
+}
+
+type arrayExpression struct {
+	Operator string
+	Column   interface{}
+	Value    interface{}
+}
+
+func (a arrayExpression) Build(builder clause.Builder) {
+	buildWithDialect(builder, func(dialect Dialect) (clause.Expression, error) {
+		return dialect.Array(a.Operator, a.Column, a.Value)
+	})
+}
+
+type findInSet struct {
+	Column interface{}
+	Value  string
+}
+
+func (f findInSet) Build(builder clause.Builder) {
+	buildWithDialect(builder, func(dialect Dialect) (clause.Expression, error) {
+		return dialect.FindInSet(f.Column, f.Value)
+	})
+}
+
+// bitUpdate is the value of Column with the bits of Value set, cleared or toggled,
+// Column is a column or the bitUpdate before it on the same column
+type bitUpdate struct {
+	Operator string
+	Column   interface{}
+	Value    int64
+}
+
+func (b bitUpdate) Build(builder clause.Builder) {
+	column := b.Column
+	if prev, ok := column.(bitUpdate); ok {
+		column = clause.Expr{SQL: "(?)", Vars: []interface{}{prev}}
+	}
+	switch b.Operator {
+	case updateExprBitSet:
+		clause.Expr{SQL: "? | ?", Vars: []interface{}{column, b.Value}}.Build(builder)
+	case updateExprBitClear:
+		// ~ of a bound parameter is ambiguous in postgres, the mask is inverted here
+		clause.Expr{SQL: "? & ?", Vars: []interface{}{column, ^b.Value}}.Build(builder)
+	default:
+		buildWithDialect(builder, func(dialect Dialect) (clause.Expression, error) {
+			return dialect.BitXor(column, b.Value)
+		})
+	}
+}
+
+// isDistinct is the null safe comparison of Column and Value, Value is nil for NULL
+type isDistinct struct {
+	Column   interface{}
+	Value    interface{}
+	Distinct bool
+}
+
+func (d isDistinct) Build(builder clause.Builder) {
+	buildWithDialect(builder, func(dialect Dialect) (clause.Expression, error) {
+		return dialect.IsDistinct(d.Column, d.Value, d.Distinct)
+	})
+}
+
+func (d isDistinct) NegationBuild(builder clause.Builder) {
+	d.Distinct = !d.Distinct
+	d.Build(builder)
+}
+
+// geoWithin is Value, a GeoRadius or GeoBox, on the spatial Column
+type geoWithin struct {
+	Column clause.Column
+	Value  interface{}
+	SRID   int
+}
+
+func (g geoWithin) Build(builder clause.Builder) {
+	buildWithDialect(builder, func(dialect Dialect) (clause.Expression, error) {
+		if radius, ok := g.Value.(GeoRadius); ok {
+			return dialect.GeoWithinRadius(g.Column, radius, g.SRID)
+		}
+		boxes := g.Value.(GeoBox).split()
+		exprs := make([]clause.Expression, 0, len(boxes))
+		for _, box := range boxes {
+			expr, err := dialect.GeoWithinBox(g.Column, box, g.SRID)
+			if err != nil {
+				return nil, err
+			}
+			exprs = append(exprs, expr)
+		}
+		return joinExpression(exprs, false), nil
+	})
+}
+
+// columnFunc is the SQL function of the func tag applied to Column
+type columnFunc struct {
+	Func   string
+	Column interface{}
 }
 
-func (in notIn) Build(builder clause.Builder) {
-	in.in.NegationBuild(builder)
+func (c columnFunc) Build(builder clause.Builder) {
+	buildWithDialect(builder, func(dialect Dialect) (clause.Expression, error) {
+		return dialect.Func(c.Func, c.Column)
+	})
 }
 
 type errExpression struct {
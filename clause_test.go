@@ -12,8 +12,36 @@ func Test_Clause(t *testing.T) {
 	db := newDB()
 
 	t.Run("no in", func(t *testing.T) {
-		res := notIn{clause.IN{Column: "name", Values: []any{1, 2}}}
+		res := clause.Not(clause.IN{Column: "name", Values: []any{1, 2}})
 		res.Build(db.Statement)
 		as.Equal("`name` NOT IN (?,?)", db.Statement.SQL.String())
 	})
+
+	t.Run("between", func(t *testing.T) {
+		db := newDB()
+		res := between{Column: clause.Column{Name: "age"}, From: 1, To: 2}
+		res.Build(db.Statement)
+		as.Equal("`age` BETWEEN ? AND ?", db.Statement.SQL.String())
+		as.Equal([]interface{}{1, 2}, db.Statement.Vars)
+	})
+
+	t.Run("escaped like", func(t *testing.T) {
+		tests := []struct {
+			dialect string
+			like    escapedLike
+			sql     string
+			vars    []interface{}
+		}{
+			{"mysql", escapedLike{Column: clause.Column{Name: "name"}, Value: `50%_off\`, AnySuffix: true}, "`name` LIKE ? ESCAPE '\\\\'", []interface{}{`50\%\_off\\%`}},
+			{"postgres", escapedLike{Column: clause.Column{Name: "name"}, Value: "50%", AnyPrefix: true}, `"name" LIKE ? ESCAPE '\'`, []interface{}{`%50\%`}},
+			{"sqlite", escapedLike{Column: clause.Column{Name: "name"}, Value: "a_b", AnyPrefix: true, AnySuffix: true}, `"name" LIKE ? ESCAPE '\'`, []interface{}{`%a\_b%`}},
+			{"sqlserver", escapedLike{Column: clause.Column{Name: "name"}, Value: "[a]", AnySuffix: true}, `[name] LIKE ? ESCAPE '\'`, []interface{}{`\[a]%`}},
+		}
+		for _, tt := range tests {
+			db := newDialectDB(tt.dialect)
+			tt.like.Build(db.Statement)
+			as.Equal(tt.sql, db.Statement.SQL.String(), tt.dialect)
+			as.Equal(tt.vars, db.Statement.Vars, tt.dialect)
+		}
+	})
 }
@@ -0,0 +1,790 @@
+package gormx
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrUnsupported is returned when an operator has no equivalent on the dialect
+var ErrUnsupported = errors.New("gormx: operator not supported by dialect")
+
+// Dialect renders the operators whose SQL differs between databases, it is
+// chosen by the gorm Dialector name of the statement being built.
+type Dialect interface {
+	// Name is the gorm Dialector name
+	Name() string
+	// EscapeLike escapes the LIKE wildcards of s, escape is the SQL literal
+	// of the escape character used in the ESCAPE clause
+	EscapeLike(s string) (pattern, escape string)
+	// MergeJSON builds the value of column with patch merged in by RFC 7396,
+	// a NULL or empty column is merged as {}
+	MergeJSON(column clause.Column, patch string) (clause.Expression, error)
+	// JSONSet builds doc with the json text value set at path, doc is a column
+	// or an expression of its document
+	JSONSet(doc interface{}, path JSONPath, value string) (clause.Expression, error)
+	// JSONRemove builds doc with path removed
+	JSONRemove(doc interface{}, path JSONPath) (clause.Expression, error)
+	// JSONArrayAppend builds doc with the json text value appended to the array at path
+	JSONArrayAppend(doc interface{}, path JSONPath, value string) (clause.Expression, error)
+	// JSONExtract builds the value at path of the json document in column, valueType
+	// is text, number or boolean, the type it is compared as
+	JSONExtract(column clause.Column, path JSONPath, valueType string) (clause.Expression, error)
+	// JSONContains builds whether the document at path of column contains the json
+	// text value, a nil path is the whole document
+	JSONContains(column clause.Column, path JSONPath, value string) (clause.Expression, error)
+	// FullText builds the full-text match of value against columns in mode natural
+	// or boolean, it is also the relevance when used in ORDER BY
+	FullText(columns []clause.Column, value string, mode string) (clause.Expression, error)
+	// Regexp builds whether column matches pattern, or not matches when not is true
+	Regexp(column interface{}, pattern string, not, ignoreCase bool) (clause.Expression, error)
+	// Array builds the array operator on column, value is a slice for array_contains,
+	// array_overlaps and array_contained_by, an element for any
+	Array(operator string, column interface{}, value interface{}) (clause.Expression, error)
+	// FindInSet builds whether value is an item of the comma separated column
+	FindInSet(column interface{}, value string) (clause.Expression, error)
+	// BitXor builds column XOR value
+	BitXor(column interface{}, value int64) (clause.Expression, error)
+	// IsDistinct builds the null safe comparison of column and value, value is nil
+	// for NULL, distinct is IS DISTINCT FROM and not distinct is null safe equal
+	IsDistinct(column interface{}, value interface{}, distinct bool) (clause.Expression, error)
+	// GeoWithinRadius builds whether the point of the spatial column is within radius,
+	// srid is the srid tag, 0 when not set
+	GeoWithinRadius(column clause.Column, radius GeoRadius, srid int) (clause.Expression, error)
+	// GeoWithinBox builds whether the point of the spatial column is within box
+	GeoWithinBox(column clause.Column, box GeoBox, srid int) (clause.Expression, error)
+	// Func builds the SQL function of the func tag applied to column, name is one of
+	// lower, upper, trim, length and date
+	Func(name string, column interface{}) (clause.Expression, error)
+	// ILike builds column LIKE pattern case-insensitively, pattern is escaped by EscapeLike
+	// and escape is its escape character
+	ILike(column interface{}, pattern, escape string, not bool) (clause.Expression, error)
+}
+
+var dialectMap = sync.Map{}
+
+func init() {
+	for _, dialect := range []Dialect{mysqlDialect{}, postgresDialect{}, sqliteDialect{}, sqlserverDialect{}} {
+		RegisterDialect(dialect.Name(), dialect)
+	}
+}
+
+// RegisterDialect registers dialect for the gorm Dialector name
+func RegisterDialect(name string, dialect Dialect) {
+	dialectMap.Store(name, dialect)
+}
+
+// GetDialect returns the dialect registered for the gorm Dialector name
+func GetDialect(name string) (dialect Dialect, ok bool) {
+	v, ok := dialectMap.Load(name)
+	if ok {
+		dialect, ok = v.(Dialect)
+	}
+	return dialect, ok
+}
+
+func dialectOf(builder clause.Builder) (Dialect, error) {
+	stmt, ok := builder.(*gorm.Statement)
+	if !ok || stmt.DB == nil || stmt.DB.Dialector == nil {
+		return nil, fmt.Errorf("gormx: can not get dialect from %T", builder)
+	}
+	name := stmt.DB.Dialector.Name()
+	dialect, ok := GetDialect(name)
+	if !ok {
+		return nil, fmt.Errorf("gormx: dialect %s not registered", name)
+	}
+	return dialect, nil
+}
+
+// buildWithDialect writes the expression build returns for the statement's dialect
+func buildWithDialect(builder clause.Builder, build func(dialect Dialect) (clause.Expression, error)) {
+	dialect, err := dialectOf(builder)
+	if err == nil {
+		var expr clause.Expression
+		if expr, err = build(dialect); err == nil {
+			expr.Build(builder)
+			return
+		}
+	}
+	_ = builder.AddError(err)
+}
+
+func errUnsupported(dialect Dialect, operator string) error {
+	return fmt.Errorf("%w: %s on %s", ErrUnsupported, operator, dialect.Name())
+}
+
+func escapeLike(s, specials string) string {
+	var sb strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(specials, r) {
+			sb.WriteByte('\\')
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+type mysqlDialect struct{}
+
+func (mysqlDialect) Name() string {
+	return "mysql"
+}
+
+func (mysqlDialect) EscapeLike(s string) (string, string) {
+	// backslash is also the escape character of mysql string literals
+	return escapeLike(s, `\%_`), `'\\'`
+}
+
+func (mysqlDialect) MergeJSON(column clause.Column, patch string) (clause.Expression, error) {
+	return gorm.Expr("JSON_MERGE_PATCH(CASE WHEN (? IS NULL OR ? = '') THEN JSON_OBJECT() ELSE ? END, CAST(? AS JSON))", column, column, column, patch), nil
+}
+
+func (mysqlDialect) JSONSet(doc interface{}, path JSONPath, value string) (clause.Expression, error) {
+	if column, ok := doc.(clause.Column); ok {
+		doc = gorm.Expr("CASE WHEN (? IS NULL OR ? = '') THEN JSON_OBJECT() ELSE ? END", column, column, column)
+	}
+	return gorm.Expr("JSON_SET(?, ?, CAST(? AS JSON))", doc, path.String(), value), nil
+}
+
+func (mysqlDialect) JSONRemove(doc interface{}, path JSONPath) (clause.Expression, error) {
+	return gorm.Expr("JSON_REMOVE(?, ?)", doc, path.String()), nil
+}
+
+func (mysqlDialect) JSONArrayAppend(doc interface{}, path JSONPath, value string) (clause.Expression, error) {
+	return gorm.Expr("JSON_ARRAY_APPEND(?, ?, CAST(? AS JSON))", doc, path.String(), value), nil
+}
+
+func (mysqlDialect) JSONExtract(column clause.Column, path JSONPath, valueType string) (clause.Expression, error) {
+	switch valueType {
+	case jsonTypeNumber:
+		// BETWEEN and IN do not compare json values as numbers
+		return gorm.Expr("CAST(JSON_EXTRACT(?, ?) AS DECIMAL(65,30))", column, path.String()), nil
+	case jsonTypeBoolean:
+		return gorm.Expr("(JSON_EXTRACT(?, ?) = CAST('true' AS JSON))", column, path.String()), nil
+	default:
+		return gorm.Expr("JSON_UNQUOTE(JSON_EXTRACT(?, ?))", column, path.String()), nil
+	}
+}
+
+func (mysqlDialect) JSONContains(column clause.Column, path JSONPath, value string) (clause.Expression, error) {
+	if path == nil {
+		return gorm.Expr("JSON_CONTAINS(?, CAST(? AS JSON))", column, value), nil
+	}
+	return gorm.Expr("JSON_CONTAINS(?, CAST(? AS JSON), ?)", column, value, path.String()), nil
+}
+
+func (mysqlDialect) FullText(columns []clause.Column, value string, mode string) (clause.Expression, error) {
+	modifier := "IN NATURAL LANGUAGE MODE"
+	if mode == fullTextModeBoolean {
+		modifier = "IN BOOLEAN MODE"
+	}
+	vars := make([]interface{}, 0, len(columns)+1)
+	for _, column := range columns {
+		vars = append(vars, column)
+	}
+	vars = append(vars, value)
+	sql := "MATCH(" + strings.TrimSuffix(strings.Repeat("?,", len(columns)), ",") + ") AGAINST(? " + modifier + ")"
+	return clause.Expr{SQL: sql, Vars: vars, WithoutParentheses: true}, nil
+}
+
+func (mysqlDialect) Regexp(column interface{}, pattern string, not, ignoreCase bool) (clause.Expression, error) {
+	sql := "? REGEXP ?"
+	if ignoreCase {
+		// REGEXP follows the collation of column, match_type i does not
+		sql = "REGEXP_LIKE(?, ?, 'i')"
+	}
+	if not {
+		sql = "NOT " + sql
+	}
+	return clause.Expr{SQL: sql, Vars: []interface{}{column, pattern}, WithoutParentheses: true}, nil
+}
+
+func (d mysqlDialect) Array(operator string, column interface{}, value interface{}) (clause.Expression, error) {
+	return nil, errUnsupported(d, operator)
+}
+
+func (mysqlDialect) FindInSet(column interface{}, value string) (clause.Expression, error) {
+	return clause.Expr{SQL: "FIND_IN_SET(?, ?) > 0", Vars: []interface{}{value, column}, WithoutParentheses: true}, nil
+}
+
+func (mysqlDialect) BitXor(column interface{}, value int64) (clause.Expression, error) {
+	return clause.Expr{SQL: "? ^ ?", Vars: []interface{}{column, value}, WithoutParentheses: true}, nil
+}
+
+func (mysqlDialect) IsDistinct(column interface{}, value interface{}, distinct bool) (clause.Expression, error) {
+	if distinct {
+		return clause.Expr{SQL: "NOT (? <=> ?)", Vars: []interface{}{column, value}, WithoutParentheses: true}, nil
+	}
+	return clause.Expr{SQL: "? <=> ?", Vars: []interface{}{column, value}, WithoutParentheses: true}, nil
+}
+
+func (mysqlDialect) GeoWithinRadius(column clause.Column, radius GeoRadius, srid int) (clause.Expression, error) {
+	if srid == 0 {
+		return gorm.Expr("ST_Distance_Sphere(?, POINT(?, ?)) <= ?", column, radius.Lng, radius.Lat, radius.Meters), nil
+	}
+	// POINT is x y, that is lng lat, ST_SRID keeps the coordinates
+	return gorm.Expr("ST_Distance_Sphere(?, ST_SRID(POINT(?, ?), ?)) <= ?", column, radius.Lng, radius.Lat, srid, radius.Meters), nil
+}
+
+func (mysqlDialect) GeoWithinBox(column clause.Column, box GeoBox, srid int) (clause.Expression, error) {
+	if srid == 0 {
+		return gorm.Expr("MBRContains(ST_GeomFromText(?), ?)", box.polygon(), column), nil
+	}
+	return gorm.Expr("MBRContains(ST_GeomFromText(?, ?, 'axis-order=long-lat'), ?)", box.polygon(), srid, column), nil
+}
+
+func (d mysqlDialect) Func(name string, column interface{}) (clause.Expression, error) {
+	// LENGTH is the bytes in mysql
+	switch name {
+	case funcLower:
+		return clause.Expr{SQL: "LOWER(?)", Vars: []interface{}{column}, WithoutParentheses: true}, nil
+	case funcUpper:
+		return clause.Expr{SQL: "UPPER(?)", Vars: []interface{}{column}, WithoutParentheses: true}, nil
+	case funcTrim:
+		return clause.Expr{SQL: "TRIM(?)", Vars: []interface{}{column}, WithoutParentheses: true}, nil
+	case funcLength:
+		return clause.Expr{SQL: "CHAR_LENGTH(?)", Vars: []interface{}{column}, WithoutParentheses: true}, nil
+	case funcDate:
+		return clause.Expr{SQL: "DATE(?)", Vars: []interface{}{column}, WithoutParentheses: true}, nil
+	}
+	return nil, errUnsupported(d, "func "+name)
+}
+
+func (mysqlDialect) ILike(column interface{}, pattern, escape string, not bool) (clause.Expression, error) {
+	// LIKE follows the collation of column, which may be case-sensitive
+	if not {
+		return clause.Expr{SQL: "LOWER(?) NOT LIKE ? ESCAPE " + escape, Vars: []interface{}{column, strings.ToLower(pattern)}}, nil
+	}
+	return clause.Expr{SQL: "LOWER(?) LIKE ? ESCAPE " + escape, Vars: []interface{}{column, strings.ToLower(pattern)}}, nil
+}
+
+type postgresDialect struct{}
+
+func (postgresDialect) Name() string {
+	return "postgres"
+}
+
+func (postgresDialect) EscapeLike(s string) (string, string) {
+	return escapeLike(s, `\%_`), `'\'`
+}
+
+func (postgresDialect) MergeJSON(column clause.Column, patch string) (clause.Expression, error) {
+	var v interface{}
+	decoder := json.NewDecoder(strings.NewReader(patch))
+	decoder.UseNumber()
+	if err := decoder.Decode(&v); err != nil {
+		return nil, err
+	}
+	if obj, ok := v.(map[string]interface{}); ok {
+		return postgresMergePatch(column, obj), nil
+	}
+	// a patch which is not an object replaces the whole document
+	return gorm.Expr("?::jsonb", patch), nil
+}
+
+// postgresMergePatch merges patch into target like RFC 7396: null deletes the
+// key, an object is merged recursively and any other value replaces the key.
+func postgresMergePatch(target interface{}, patch map[string]interface{}) clause.Expression {
+	keys := make([]string, 0, len(patch))
+	for k := range patch {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	sql := "(CASE WHEN jsonb_typeof(?) = 'object' THEN ? ELSE '{}'::jsonb END"
+	vars := []interface{}{target, target}
+	values := map[string]interface{}{}
+	var deletes []string
+	for _, k := range keys {
+		switch v := patch[k].(type) {
+		case nil:
+			deletes = append(deletes, k)
+		case map[string]interface{}:
+			sql += " || jsonb_build_object(?::text, ?)"
+			vars = append(vars, k, postgresMergePatch(gorm.Expr("? -> ?::text", target, k), v))
+		default:
+			values[k] = v
+		}
+	}
+	if len(values) > 0 {
+		bs, _ := json.Marshal(values)
+		sql += " || ?::jsonb"
+		vars = append(vars, string(bs))
+	}
+	sql += ")"
+	// - binds tighter than ||, so the keys are deleted from the merged object
+	if len(deletes) > 0 {
+		sql = "(" + sql
+		for _, k := range deletes {
+			sql += " - ?::text"
+			vars = append(vars, k)
+		}
+		sql += ")"
+	}
+	return gorm.Expr(sql, vars...)
+}
+
+func (postgresDialect) JSONSet(doc interface{}, path JSONPath, value string) (clause.Expression, error) {
+	return gorm.Expr("jsonb_set(COALESCE(?, '{}'::jsonb), ?::text[], ?::jsonb)", doc, path.Array(), value), nil
+}
+
+func (postgresDialect) JSONRemove(doc interface{}, path JSONPath) (clause.Expression, error) {
+	return gorm.Expr("(? #- ?::text[])", doc, path.Array()), nil
+}
+
+func (postgresDialect) JSONArrayAppend(doc interface{}, path JSONPath, value string) (clause.Expression, error) {
+	// jsonb_set returns NULL when path is missing, the document is kept like mysql does
+	return gorm.Expr("COALESCE(jsonb_set(?, ?::text[], (? #> ?::text[]) || jsonb_build_array(?::jsonb), false), ?)",
+		doc, path.Array(), doc, path.Array(), value, doc), nil
+}
+
+func (postgresDialect) JSONExtract(column clause.Column, path JSONPath, valueType string) (clause.Expression, error) {
+	switch valueType {
+	case jsonTypeNumber:
+		return gorm.Expr("(? #>> ?::text[])::numeric", column, path.Array()), nil
+	case jsonTypeBoolean:
+		return gorm.Expr("(? #>> ?::text[])::boolean", column, path.Array()), nil
+	default:
+		return gorm.Expr("(? #>> ?::text[])", column, path.Array()), nil
+	}
+}
+
+func (postgresDialect) JSONContains(column clause.Column, path JSONPath, value string) (clause.Expression, error) {
+	if path == nil {
+		return gorm.Expr("? @> ?::jsonb", column, value), nil
+	}
+	return gorm.Expr("(? #> ?::text[]) @> ?::jsonb", column, path.Array(), value), nil
+}
+
+func (d postgresDialect) FullText(columns []clause.Column, value string, mode string) (clause.Expression, error) {
+	return nil, errUnsupported(d, operatorFullText)
+}
+
+func (postgresDialect) Regexp(column interface{}, pattern string, not, ignoreCase bool) (clause.Expression, error) {
+	op := "~"
+	if not {
+		op = "!~"
+	}
+	if ignoreCase {
+		op += "*"
+	}
+	return clause.Expr{SQL: "? " + op + " ?", Vars: []interface{}{column, pattern}, WithoutParentheses: true}, nil
+}
+
+func (d postgresDialect) Array(operator string, column interface{}, value interface{}) (clause.Expression, error) {
+	switch operator {
+	case operatorArrayContains:
+		return clause.Expr{SQL: "? @> ?", Vars: []interface{}{column, postgresArray(interfaceToSlice(value))}, WithoutParentheses: true}, nil
+	case operatorArrayOverlaps:
+		return clause.Expr{SQL: "? && ?", Vars: []interface{}{column, postgresArray(interfaceToSlice(value))}, WithoutParentheses: true}, nil
+	case operatorArrayContainedBy:
+		return clause.Expr{SQL: "? <@ ?", Vars: []interface{}{column, postgresArray(interfaceToSlice(value))}, WithoutParentheses: true}, nil
+	case operatorAny:
+		return clause.Expr{SQL: "? = ANY(?)", Vars: []interface{}{value, column}, WithoutParentheses: true}, nil
+	}
+	return nil, errUnsupported(d, operator)
+}
+
+func (postgresDialect) FindInSet(column interface{}, value string) (clause.Expression, error) {
+	return clause.Expr{SQL: "? = ANY(string_to_array(?, ','))", Vars: []interface{}{value, column}, WithoutParentheses: true}, nil
+}
+
+func (postgresDialect) BitXor(column interface{}, value int64) (clause.Expression, error) {
+	// ^ is power in postgres
+	return clause.Expr{SQL: "? # ?", Vars: []interface{}{column, value}, WithoutParentheses: true}, nil
+}
+
+func (postgresDialect) IsDistinct(column interface{}, value interface{}, distinct bool) (clause.Expression, error) {
+	if distinct {
+		return clause.Expr{SQL: "? IS DISTINCT FROM ?", Vars: []interface{}{column, value}, WithoutParentheses: true}, nil
+	}
+	return clause.Expr{SQL: "? IS NOT DISTINCT FROM ?", Vars: []interface{}{column, value}, WithoutParentheses: true}, nil
+}
+
+func (postgresDialect) GeoWithinRadius(column clause.Column, radius GeoRadius, srid int) (clause.Expression, error) {
+	if srid == 0 {
+		srid = 4326
+	}
+	// geography measures in meters
+	return gorm.Expr("ST_DWithin(?::geography, ST_SetSRID(ST_MakePoint(?, ?), ?)::geography, ?)", column, radius.Lng, radius.Lat, srid, radius.Meters), nil
+}
+
+func (postgresDialect) GeoWithinBox(column clause.Column, box GeoBox, srid int) (clause.Expression, error) {
+	if srid == 0 {
+		srid = 4326
+	}
+	return gorm.Expr("? && ST_MakeEnvelope(?, ?, ?, ?, ?)", column, box.MinLng, box.MinLat, box.MaxLng, box.MaxLat, srid), nil
+}
+
+func (d postgresDialect) Func(name string, column interface{}) (clause.Expression, error) {
+	switch name {
+	case funcLower:
+		return clause.Expr{SQL: "LOWER(?)", Vars: []interface{}{column}, WithoutParentheses: true}, nil
+	case funcUpper:
+		return clause.Expr{SQL: "UPPER(?)", Vars: []interface{}{column}, WithoutParentheses: true}, nil
+	case funcTrim:
+		return clause.Expr{SQL: "TRIM(?)", Vars: []interface{}{column}, WithoutParentheses: true}, nil
+	case funcLength:
+		return clause.Expr{SQL: "LENGTH(?)", Vars: []interface{}{column}, WithoutParentheses: true}, nil
+	case funcDate:
+		return clause.Expr{SQL: "CAST(? AS DATE)", Vars: []interface{}{column}, WithoutParentheses: true}, nil
+	}
+	return nil, errUnsupported(d, "func "+name)
+}
+
+func (postgresDialect) ILike(column interface{}, pattern, escape string, not bool) (clause.Expression, error) {
+	if not {
+		return clause.Expr{SQL: "? NOT ILIKE ? ESCAPE " + escape, Vars: []interface{}{column, pattern}}, nil
+	}
+	return clause.Expr{SQL: "? ILIKE ? ESCAPE " + escape, Vars: []interface{}{column, pattern}}, nil
+}
+
+// postgresArray binds a slice as one array parameter in the text form {a,"b c"},
+// the server casts it to the array type of the column
+type postgresArray []interface{}
+
+func (a postgresArray) Value() (driver.Value, error) {
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, v := range a {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		element, err := postgresArrayElement(v)
+		if err != nil {
+			return nil, err
+		}
+		b.WriteString(element)
+	}
+	b.WriteByte('}')
+	return b.String(), nil
+}
+
+func postgresArrayElement(v interface{}) (string, error) {
+	if valuer, ok := v.(driver.Valuer); ok {
+		value, err := valuer.Value()
+		if err != nil {
+			return "", err
+		}
+		v = value
+	}
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return "NULL", nil
+		}
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() {
+		return "NULL", nil
+	}
+
+	switch v := rv.Interface().(type) {
+	case bool:
+		return strconv.FormatBool(v), nil
+	case time.Time:
+		return `"` + v.Format(time.RFC3339Nano) + `"`, nil
+	case []byte:
+		return postgresArrayQuote(string(v)), nil
+	}
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return fmt.Sprint(rv.Interface()), nil
+	case reflect.String:
+		return postgresArrayQuote(rv.String()), nil
+	}
+	return "", fmt.Errorf("gormx: %T can not be an element of postgres array", v)
+}
+
+func postgresArrayQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+type sqliteDialect struct{}
+
+func (sqliteDialect) Name() string {
+	return "sqlite"
+}
+
+func (sqliteDialect) EscapeLike(s string) (string, string) {
+	return escapeLike(s, `\%_`), `'\'`
+}
+
+func (sqliteDialect) MergeJSON(column clause.Column, patch string) (clause.Expression, error) {
+	return gorm.Expr("json_patch(COALESCE(NULLIF(?, ''), '{}'), ?)", column, patch), nil
+}
+
+func (sqliteDialect) JSONSet(doc interface{}, path JSONPath, value string) (clause.Expression, error) {
+	return gorm.Expr("json_set(COALESCE(NULLIF(?, ''), '{}'), ?, json(?))", doc, path.String(), value), nil
+}
+
+func (sqliteDialect) JSONRemove(doc interface{}, path JSONPath) (clause.Expression, error) {
+	return gorm.Expr("json_remove(?, ?)", doc, path.String()), nil
+}
+
+func (sqliteDialect) JSONArrayAppend(doc interface{}, path JSONPath, value string) (clause.Expression, error) {
+	return gorm.Expr("CASE json_type(?, ?) WHEN 'array' THEN json_insert(?, ?, json(?)) ELSE ? END",
+		doc, path.String(), doc, path.String()+"[#]", value, doc), nil
+}
+
+func (sqliteDialect) JSONExtract(column clause.Column, path JSONPath, valueType string) (clause.Expression, error) {
+	// json_extract returns sql values, true and false are 1 and 0
+	return gorm.Expr("json_extract(?, ?)", column, path.String()), nil
+}
+
+func (d sqliteDialect) JSONContains(column clause.Column, path JSONPath, value string) (clause.Expression, error) {
+	// json_each has no key-aware containment, only a scalar or an array of scalars is matched
+	var want interface{}
+	if err := json.Unmarshal([]byte(value), &want); err != nil {
+		return nil, err
+	}
+	elems, ok := want.([]interface{})
+	if !ok {
+		elems = []interface{}{want}
+	}
+	for _, elem := range elems {
+		switch elem.(type) {
+		case map[string]interface{}, []interface{}:
+			return nil, errUnsupported(d, operatorJSONContains+" of objects or nested arrays")
+		}
+	}
+
+	// every element of value, or value itself when it is not an array, is the document or
+	// one of its elements, the members of an object have text keys and are skipped
+	doc := []interface{}{value, column}
+	each := "json_each(?)"
+	if path != nil {
+		doc = append(doc, path.String())
+		each = "json_each(?, ?)"
+	}
+	return gorm.Expr("NOT EXISTS (SELECT 1 FROM json_each(json(?)) AS want WHERE NOT EXISTS (SELECT 1 FROM "+each+
+		" AS got WHERE typeof(got.key) <> 'text' AND got.type = want.type AND got.value = want.value))", doc...), nil
+}
+
+func (d sqliteDialect) FullText(columns []clause.Column, value string, mode string) (clause.Expression, error) {
+	return nil, errUnsupported(d, operatorFullText)
+}
+
+func (sqliteDialect) Regexp(column interface{}, pattern string, not, ignoreCase bool) (clause.Expression, error) {
+	// REGEXP calls the regexp() function registered by the driver, which takes the flag inline
+	if ignoreCase {
+		pattern = "(?i)" + pattern
+	}
+	sql := "? REGEXP ?"
+	if not {
+		sql = "? NOT REGEXP ?"
+	}
+	return clause.Expr{SQL: sql, Vars: []interface{}{column, pattern}, WithoutParentheses: true}, nil
+}
+
+func (d sqliteDialect) Array(operator string, column interface{}, value interface{}) (clause.Expression, error) {
+	return nil, errUnsupported(d, operator)
+}
+
+func (sqliteDialect) FindInSet(column interface{}, value string) (clause.Expression, error) {
+	return clause.Expr{SQL: "instr(',' || ? || ',', ',' || ? || ',') > 0", Vars: []interface{}{column, value}, WithoutParentheses: true}, nil
+}
+
+func (sqliteDialect) BitXor(column interface{}, value int64) (clause.Expression, error) {
+	// sqlite has no xor operator
+	return clause.Expr{SQL: "(? | ?) - (? & ?)", Vars: []interface{}{column, value, column, value}, WithoutParentheses: true}, nil
+}
+
+func (sqliteDialect) IsDistinct(column interface{}, value interface{}, distinct bool) (clause.Expression, error) {
+	// IS and IS NOT are null safe, IS [NOT] DISTINCT FROM needs sqlite 3.39
+	if distinct {
+		return clause.Expr{SQL: "? IS NOT ?", Vars: []interface{}{column, value}, WithoutParentheses: true}, nil
+	}
+	return clause.Expr{SQL: "? IS ?", Vars: []interface{}{column, value}, WithoutParentheses: true}, nil
+}
+
+func (d sqliteDialect) GeoWithinRadius(column clause.Column, radius GeoRadius, srid int) (clause.Expression, error) {
+	return nil, errUnsupported(d, operatorWithinRadius)
+}
+
+func (d sqliteDialect) GeoWithinBox(column clause.Column, box GeoBox, srid int) (clause.Expression, error) {
+	return nil, errUnsupported(d, operatorWithinBox)
+}
+
+func (d sqliteDialect) Func(name string, column interface{}) (clause.Expression, error) {
+	switch name {
+	case funcLower:
+		return clause.Expr{SQL: "LOWER(?)", Vars: []interface{}{column}, WithoutParentheses: true}, nil
+	case funcUpper:
+		return clause.Expr{SQL: "UPPER(?)", Vars: []interface{}{column}, WithoutParentheses: true}, nil
+	case funcTrim:
+		return clause.Expr{SQL: "TRIM(?)", Vars: []interface{}{column}, WithoutParentheses: true}, nil
+	case funcLength:
+		return clause.Expr{SQL: "LENGTH(?)", Vars: []interface{}{column}, WithoutParentheses: true}, nil
+	case funcDate:
+		return clause.Expr{SQL: "DATE(?)", Vars: []interface{}{column}, WithoutParentheses: true}, nil
+	}
+	return nil, errUnsupported(d, "func "+name)
+}
+
+func (sqliteDialect) ILike(column interface{}, pattern, escape string, not bool) (clause.Expression, error) {
+	if not {
+		return clause.Expr{SQL: "LOWER(?) NOT LIKE ? ESCAPE " + escape, Vars: []interface{}{column, strings.ToLower(pattern)}}, nil
+	}
+	return clause.Expr{SQL: "LOWER(?) LIKE ? ESCAPE " + escape, Vars: []interface{}{column, strings.ToLower(pattern)}}, nil
+}
+
+type sqlserverDialect struct{}
+
+func (sqlserverDialect) Name() string {
+	return "sqlserver"
+}
+
+func (sqlserverDialect) EscapeLike(s string) (string, string) {
+	// [ starts a character class in sqlserver LIKE patterns
+	return escapeLike(s, `\%_[`), `'\'`
+}
+
+func (d sqlserverDialect) MergeJSON(column clause.Column, patch string) (clause.Expression, error) {
+	return nil, errUnsupported(d, updateExprMergeJSON)
+}
+
+func (d sqlserverDialect) JSONSet(doc interface{}, path JSONPath, value string) (clause.Expression, error) {
+	v, err := sqlserverJSONValue(d, updateExprJSONSet, value)
+	if err != nil {
+		return nil, err
+	}
+	return gorm.Expr("JSON_MODIFY(COALESCE(NULLIF(?, ''), '{}'), ?, ?)", doc, path.String(), v), nil
+}
+
+func (sqlserverDialect) JSONRemove(doc interface{}, path JSONPath) (clause.Expression, error) {
+	// NULL deletes the key in the lax mode
+	return gorm.Expr("JSON_MODIFY(?, ?, NULL)", doc, path.String()), nil
+}
+
+func (d sqlserverDialect) JSONArrayAppend(doc interface{}, path JSONPath, value string) (clause.Expression, error) {
+	v, err := sqlserverJSONValue(d, updateExprJSONArrayAppend, value)
+	if err != nil {
+		return nil, err
+	}
+	return gorm.Expr("JSON_MODIFY(?, ?, ?)", doc, "append "+path.String(), v), nil
+}
+
+func (sqlserverDialect) JSONExtract(column clause.Column, path JSONPath, valueType string) (clause.Expression, error) {
+	switch valueType {
+	case jsonTypeNumber:
+		return gorm.Expr("CAST(JSON_VALUE(?, ?) AS FLOAT)", column, path.String()), nil
+	case jsonTypeBoolean:
+		return gorm.Expr("CASE JSON_VALUE(?, ?) WHEN 'true' THEN 1 WHEN 'false' THEN 0 END", column, path.String()), nil
+	default:
+		return gorm.Expr("JSON_VALUE(?, ?)", column, path.String()), nil
+	}
+}
+
+func (d sqlserverDialect) JSONContains(column clause.Column, path JSONPath, value string) (clause.Expression, error) {
+	return nil, errUnsupported(d, operatorJSONContains)
+}
+
+func (d sqlserverDialect) FullText(columns []clause.Column, value string, mode string) (clause.Expression, error) {
+	return nil, errUnsupported(d, operatorFullText)
+}
+
+func (d sqlserverDialect) Regexp(column interface{}, pattern string, not, ignoreCase bool) (clause.Expression, error) {
+	if not {
+		return nil, errUnsupported(d, operatorNotRegexp)
+	}
+	return nil, errUnsupported(d, operatorRegexp)
+}
+
+func (d sqlserverDialect) Array(operator string, column interface{}, value interface{}) (clause.Expression, error) {
+	return nil, errUnsupported(d, operator)
+}
+
+func (sqlserverDialect) FindInSet(column interface{}, value string) (clause.Expression, error) {
+	return clause.Expr{SQL: "? IN (SELECT value FROM STRING_SPLIT(?, ','))", Vars: []interface{}{value, column}, WithoutParentheses: true}, nil
+}
+
+func (sqlserverDialect) BitXor(column interface{}, value int64) (clause.Expression, error) {
+	return clause.Expr{SQL: "? ^ ?", Vars: []interface{}{column, value}, WithoutParentheses: true}, nil
+}
+
+func (sqlserverDialect) IsDistinct(column interface{}, value interface{}, distinct bool) (clause.Expression, error) {
+	// IS [NOT] DISTINCT FROM needs sql server 2022, value is known so NULL is handled here
+	switch {
+	case value == nil && distinct:
+		return clause.Expr{SQL: "? IS NOT NULL", Vars: []interface{}{column}, WithoutParentheses: true}, nil
+	case value == nil:
+		return clause.Expr{SQL: "? IS NULL", Vars: []interface{}{column}, WithoutParentheses: true}, nil
+	case distinct:
+		return clause.Expr{SQL: "(? <> ? OR ? IS NULL)", Vars: []interface{}{column, value, column}, WithoutParentheses: true}, nil
+	default:
+		return clause.Expr{SQL: "? = ?", Vars: []interface{}{column, value}, WithoutParentheses: true}, nil
+	}
+}
+
+func (sqlserverDialect) GeoWithinRadius(column clause.Column, radius GeoRadius, srid int) (clause.Expression, error) {
+	if srid == 0 {
+		srid = 4326
+	}
+	return gorm.Expr("?.STDistance(geography::Point(?, ?, ?)) <= ?", column, radius.Lat, radius.Lng, srid, radius.Meters), nil
+}
+
+func (d sqlserverDialect) GeoWithinBox(column clause.Column, box GeoBox, srid int) (clause.Expression, error) {
+	return nil, errUnsupported(d, operatorWithinBox)
+}
+
+func (d sqlserverDialect) Func(name string, column interface{}) (clause.Expression, error) {
+	// LEN ignores the trailing spaces
+	switch name {
+	case funcLower:
+		return clause.Expr{SQL: "LOWER(?)", Vars: []interface{}{column}, WithoutParentheses: true}, nil
+	case funcUpper:
+		return clause.Expr{SQL: "UPPER(?)", Vars: []interface{}{column}, WithoutParentheses: true}, nil
+	case funcTrim:
+		return clause.Expr{SQL: "TRIM(?)", Vars: []interface{}{column}, WithoutParentheses: true}, nil
+	case funcLength:
+		return clause.Expr{SQL: "LEN(?)", Vars: []interface{}{column}, WithoutParentheses: true}, nil
+	case funcDate:
+		return clause.Expr{SQL: "CAST(? AS DATE)", Vars: []interface{}{column}, WithoutParentheses: true}, nil
+	}
+	return nil, errUnsupported(d, "func "+name)
+}
+
+func (sqlserverDialect) ILike(column interface{}, pattern, escape string, not bool) (clause.Expression, error) {
+	if not {
+		return clause.Expr{SQL: "LOWER(?) NOT LIKE ? ESCAPE " + escape, Vars: []interface{}{column, strings.ToLower(pattern)}}, nil
+	}
+	return clause.Expr{SQL: "LOWER(?) LIKE ? ESCAPE " + escape, Vars: []interface{}{column, strings.ToLower(pattern)}}, nil
+}
+
+// sqlserverJSONValue converts the json text value to what JSON_MODIFY stores as it:
+// objects and arrays go through JSON_QUERY, scalars are bound with their type.
+func sqlserverJSONValue(d Dialect, operator, value string) (interface{}, error) {
+	var v interface{}
+	decoder := json.NewDecoder(strings.NewReader(value))
+	decoder.UseNumber()
+	if err := decoder.Decode(&v); err != nil {
+		return nil, err
+	}
+	switch v := v.(type) {
+	case nil:
+		// JSON_MODIFY deletes the key instead of storing null
+		return nil, errUnsupported(d, operator+" null")
+	case map[string]interface{}, []interface{}:
+		return gorm.Expr("JSON_QUERY(?)", value), nil
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i, nil
+		}
+		return v.Float64()
+	default:
+		return v, nil
+	}
+}
@@ -0,0 +1,482 @@
+package gormx
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// testDialector renders like the named dialect without a database behind it
+type testDialector struct {
+	gorm.Dialector
+	name string
+}
+
+func (d testDialector) Name() string {
+	return d.name
+}
+
+func (d testDialector) QuoteTo(writer clause.Writer, str string) {
+	switch d.name {
+	case "mysql":
+		d.Dialector.QuoteTo(writer, str)
+	case "sqlserver":
+		_, _ = writer.WriteString("[" + str + "]")
+	default:
+		_, _ = writer.WriteString(`"` + str + `"`)
+	}
+}
+
+func newDialectDB(name string) *gorm.DB {
+	dbDSN := os.Getenv("GORM_DSN")
+	if dbDSN == "" {
+		dbDSN = "gorm:gorm@tcp(localhost:9910)/gorm?charset=utf8&parseTime=True&loc=Local"
+	}
+	db, err := gorm.Open(testDialector{
+		Dialector: mysql.New(mysql.Config{DSN: dbDSN, SkipInitializeWithVersion: true}),
+		name:      name,
+	}, &gorm.Config{DryRun: true, DisableAutomaticPing: true, SkipDefaultTransaction: true})
+	if err != nil {
+		panic(err)
+	}
+	return db
+}
+
+func TestDialect(t *testing.T) {
+	as := assert.New(t)
+
+	t.Run("registered", func(t *testing.T) {
+		for _, name := range []string{"mysql", "postgres", "sqlite", "sqlserver"} {
+			dialect, ok := GetDialect(name)
+			as.True(ok, name)
+			as.Equal(name, dialect.Name())
+		}
+
+		_, ok := GetDialect("clickhouse")
+		as.False(ok)
+	})
+
+	t.Run("query", func(t *testing.T) {
+		where := struct {
+			Name *string `gorm:"column:name; query_expr:starts_with"`
+			Age  *int    `gorm:"column:age; query_expr:gte"`
+		}{
+			Name: ptr("a_"),
+			Age:  ptr(18),
+		}
+		tests := []struct {
+			dialect string
+			sql     string
+		}{
+			{"postgres", `SELECT * FROM "user" WHERE ("name" LIKE 'a\_%' ESCAPE '\' AND "age" >= 18)`},
+			{"sqlite", `SELECT * FROM "user" WHERE ("name" LIKE 'a\_%' ESCAPE '\' AND "age" >= 18)`},
+			{"sqlserver", `SELECT * FROM [user] WHERE ([name] LIKE 'a\_%' ESCAPE '\' AND [age] >= 18)`},
+		}
+		for _, tt := range tests {
+			db := newDialectDB(tt.dialect)
+			as.Equal(tt.sql, db.ToSQL(func(tx *gorm.DB) *gorm.DB { return tx.Where(Query(where)).Find(&[]User{}) }), tt.dialect)
+		}
+	})
+
+	t.Run("update", func(t *testing.T) {
+		db := newDialectDB("postgres")
+		sql := db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+			return tx.Table("user").Where("id = ?", 1).Updates(Update(struct {
+				Age *int `gorm:"column:age; update_expr:+"`
+			}{Age: ptr(1)}))
+		})
+		as.Equal(`UPDATE "user" SET "age"="age" + 1 WHERE id = 1`, sql)
+	})
+
+	t.Run("merge_json", func(t *testing.T) {
+		update := struct {
+			Data map[string]interface{} `gorm:"column:data; update_expr:merge_json"`
+		}{Data: map[string]interface{}{
+			"a": 1,
+			"b": nil,
+			"c": map[string]interface{}{"d": nil, "e": []int{2}},
+		}}
+		tests := []struct {
+			dialect string
+			sql     string
+		}{
+			{"mysql", "UPDATE `user` SET `data`=JSON_MERGE_PATCH(CASE WHEN (`data` IS NULL OR `data` = '') THEN JSON_OBJECT() ELSE `data` END, CAST('{\"a\":1,\"b\":null,\"c\":{\"d\":null,\"e\":[2]}}' AS JSON)) WHERE id = 1"},
+			{"postgres", `UPDATE "user" SET "data"=((CASE WHEN jsonb_typeof("data") = 'object' THEN "data" ELSE '{}'::jsonb END || jsonb_build_object('c'::text, ((CASE WHEN jsonb_typeof("data" -> 'c'::text) = 'object' THEN "data" -> 'c'::text ELSE '{}'::jsonb END || '{"e":[2]}'::jsonb) - 'd'::text)) || '{"a":1}'::jsonb) - 'b'::text) WHERE id = 1`},
+			{"sqlite", `UPDATE "user" SET "data"=json_patch(COALESCE(NULLIF("data", ''), '{}'), '{"a":1,"b":null,"c":{"d":null,"e":[2]}}') WHERE id = 1`},
+		}
+		for _, tt := range tests {
+			db := newDialectDB(tt.dialect)
+			sql := db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+				return tx.Table("user").Where("id = ?", 1).Updates(Update(update))
+			})
+			as.Equal(tt.sql, sql, tt.dialect)
+		}
+
+		db := newDialectDB("postgres")
+		sql := db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+			return tx.Table("user").Where("id = ?", 1).Updates(Update(struct {
+				Data []int `gorm:"column:data; update_expr:merge_json"`
+			}{Data: []int{1}}))
+		})
+		as.Equal(`UPDATE "user" SET "data"='[1]'::jsonb WHERE id = 1`, sql)
+	})
+
+	t.Run("json path", func(t *testing.T) {
+		update := struct {
+			City   *string `gorm:"column:data; update_expr:json_set; path:$.profile.city"`
+			NoAge  *bool   `gorm:"column:data; update_expr:json_remove; path:$.age"`
+			AddTag *string `gorm:"column:data; update_expr:json_array_append; path:$.tags"`
+		}{
+			City:   ptr("sh"),
+			NoAge:  ptr(true),
+			AddTag: ptr("go"),
+		}
+		tests := []struct {
+			dialect string
+			sql     string
+		}{
+			{"postgres", `UPDATE "user" SET "data"=COALESCE(jsonb_set((jsonb_set(COALESCE("data", '{}'::jsonb), '{profile,city}'::text[], '"sh"'::jsonb) #- '{age}'::text[]), '{tags}'::text[], ((jsonb_set(COALESCE("data", '{}'::jsonb), '{profile,city}'::text[], '"sh"'::jsonb) #- '{age}'::text[]) #> '{tags}'::text[]) || jsonb_build_array('"go"'::jsonb), false), (jsonb_set(COALESCE("data", '{}'::jsonb), '{profile,city}'::text[], '"sh"'::jsonb) #- '{age}'::text[])) WHERE id = 1`},
+			{"sqlite", `UPDATE "user" SET "data"=CASE json_type(json_remove(json_set(COALESCE(NULLIF("data", ''), '{}'), '$.profile.city', json('"sh"')), '$.age'), '$.tags') WHEN 'array' THEN json_insert(json_remove(json_set(COALESCE(NULLIF("data", ''), '{}'), '$.profile.city', json('"sh"')), '$.age'), '$.tags[#]', json('"go"')) ELSE json_remove(json_set(COALESCE(NULLIF("data", ''), '{}'), '$.profile.city', json('"sh"')), '$.age') END WHERE id = 1`},
+			{"sqlserver", `UPDATE [user] SET [data]=JSON_MODIFY(JSON_MODIFY(JSON_MODIFY(COALESCE(NULLIF([data], ''), '{}'), '$.profile.city', 'sh'), '$.age', NULL), 'append $.tags', 'go') WHERE id = 1`},
+		}
+		for _, tt := range tests {
+			db := newDialectDB(tt.dialect)
+			sql := db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+				return tx.Table("user").Where("id = ?", 1).Updates(Update(update))
+			})
+			as.Equal(tt.sql, sql, tt.dialect)
+		}
+
+		// {"a": 1} is not contained in {"b": 1}, sqlite can not tell the keys
+		for _, value := range []interface{}{map[string]int{"a": 1}, []interface{}{[]int{1}}} {
+			err := newDialectDB("sqlite").Where(Query(struct {
+				Attrs interface{} `gorm:"column:data; query_expr:json_contains"`
+			}{Attrs: value})).Find(&[]User{}).Error
+			as.True(errors.Is(err, ErrUnsupported))
+			as.Equal("gormx: operator not supported by dialect: json_contains of objects or nested arrays on sqlite", err.Error())
+		}
+
+		db := newDialectDB("sqlserver")
+		err := db.Table("user").Where("id = ?", 1).Updates(Update(struct {
+			Data *interface{} `gorm:"column:data; update_expr:json_set; path:$.a"`
+		}{Data: new(interface{})})).Error
+		as.True(errors.Is(err, ErrUnsupported))
+	})
+
+	t.Run("json query", func(t *testing.T) {
+		query := struct {
+			Profile *struct {
+				City *string `gorm:"column:city"`
+				Age  *int    `gorm:"column:age; query_expr:>="`
+				Vip  *bool   `gorm:"column:vip"`
+			} `gorm:"column:data; query_expr:json; path:$.profile"`
+			Tags []string `gorm:"column:data; query_expr:json_contains; path:$.tags"`
+		}{Tags: []string{"go"}}
+		query.Profile = &struct {
+			City *string `gorm:"column:city"`
+			Age  *int    `gorm:"column:age; query_expr:>="`
+			Vip  *bool   `gorm:"column:vip"`
+		}{City: ptr("sh"), Age: ptr(18), Vip: ptr(true)}
+		tests := []struct {
+			dialect string
+			sql     string
+		}{
+			{"postgres", `SELECT * FROM "user" WHERE ((("data" #>> '{profile,city}'::text[]) = 'sh' AND ("data" #>> '{profile,age}'::text[])::numeric >= 18 AND ("data" #>> '{profile,vip}'::text[])::boolean = true) AND ("data" #> '{tags}'::text[]) @> '["go"]'::jsonb)`},
+			{"sqlite", `SELECT * FROM "user" WHERE ((json_extract("data", '$.profile.city') = 'sh' AND json_extract("data", '$.profile.age') >= 18 AND json_extract("data", '$.profile.vip') = true) AND NOT EXISTS (SELECT 1 FROM json_each(json('["go"]')) AS want WHERE NOT EXISTS (SELECT 1 FROM json_each("data", '$.tags') AS got WHERE typeof(got.key) <> 'text' AND got.type = want.type AND got.value = want.value)))`},
+		}
+		for _, tt := range tests {
+			db := newDialectDB(tt.dialect)
+			sql := db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+				return tx.Where(Query(query)).Find(&[]User{})
+			})
+			as.Equal(tt.sql, sql, tt.dialect)
+		}
+
+		db := newDialectDB("sqlserver")
+		sql := db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+			return tx.Where(Query(struct {
+				Profile struct {
+					Age *float64 `gorm:"column:age"`
+				} `gorm:"column:data; query_expr:json"`
+			}{Profile: struct {
+				Age *float64 `gorm:"column:age"`
+			}{Age: ptr(1.5)}})).Find(&[]User{})
+		})
+		as.Equal(`SELECT * FROM [user] WHERE CAST(JSON_VALUE([data], '$.age') AS FLOAT) = 1.500000`, sql)
+
+		err := db.Where(Query(struct {
+			Tags []string `gorm:"column:data; query_expr:json_contains"`
+		}{Tags: []string{"go"}})).Find(&[]User{}).Error
+		as.True(errors.Is(err, ErrUnsupported))
+	})
+
+	t.Run("fulltext", func(t *testing.T) {
+		for _, name := range []string{"postgres", "sqlite", "sqlserver"} {
+			db := newDialectDB(name)
+			err := db.Where(Query(struct {
+				Keyword *string `gorm:"column:title; query_expr:fulltext"`
+			}{Keyword: ptr("gorm")})).Find(&[]User{}).Error
+			as.True(errors.Is(err, ErrUnsupported), name)
+			as.Equal("gormx: operator not supported by dialect: fulltext on "+name, err.Error())
+		}
+	})
+
+	t.Run("regexp", func(t *testing.T) {
+		query := struct {
+			Sku  *string `gorm:"column:sku; query_expr:regexp; ignore_case"`
+			Name *string `gorm:"column:name; query_expr:not regexp"`
+		}{Sku: ptr("^a\\d"), Name: ptr("x")}
+		tests := []struct {
+			dialect string
+			sql     string
+		}{
+			{"postgres", `SELECT * FROM "user" WHERE ("sku" ~* '^a\d' AND "name" !~ 'x')`},
+			{"sqlite", `SELECT * FROM "user" WHERE ("sku" REGEXP '(?i)^a\d' AND "name" NOT REGEXP 'x')`},
+		}
+		for _, tt := range tests {
+			db := newDialectDB(tt.dialect)
+			sql := db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+				return tx.Where(Query(query)).Find(&[]User{})
+			})
+			as.Equal(tt.sql, sql, tt.dialect)
+		}
+
+		err := newDialectDB("sqlserver").Where(Query(query)).Find(&[]User{}).Error
+		as.Equal("gormx: operator not supported by dialect: regexp on sqlserver; gormx: operator not supported by dialect: not regexp on sqlserver", err.Error())
+	})
+
+	t.Run("array", func(t *testing.T) {
+		query := struct {
+			Tags        []string `gorm:"column:tags; query_expr:array_contains"`
+			AnyTags     []string `gorm:"column:tags; query_expr:array_overlaps"`
+			Scores      []int    `gorm:"column:scores; query_expr:array_contained_by"`
+			Tag         *string  `gorm:"column:tags; query_expr:any"`
+			EmptyTags   []string `gorm:"column:tags; query_expr:array_contains"`
+			PointerTags *[]int   `gorm:"column:scores; query_expr:array_overlaps"`
+		}{
+			Tags:    []string{"go", `a "b"`},
+			AnyTags: []string{"x,y"},
+			Scores:  []int{1, 2},
+			Tag:     ptr("go"),
+		}
+		db := newDialectDB("postgres")
+		sql := db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+			return tx.Where(Query(query)).Find(&[]User{})
+		})
+		as.Equal(`SELECT * FROM "user" WHERE ("tags" @> '{"go","a \"b\""}' AND "tags" && '{"x,y"}' AND "scores" <@ '{1,2}' AND 'go' = ANY("tags"))`, sql)
+
+		for _, name := range []string{"mysql", "sqlite", "sqlserver"} {
+			err := newDialectDB(name).Where(Query(struct {
+				Tag *string `gorm:"column:tags; query_expr:any"`
+			}{Tag: ptr("go")})).Find(&[]User{}).Error
+			as.Equal("gormx: operator not supported by dialect: any on "+name, err.Error())
+		}
+	})
+
+	t.Run("postgres array", func(t *testing.T) {
+		now := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+		value, err := postgresArray{"a", nil, (*int)(nil), ptr(1), 2.5, true, now, []byte(`\`)}.Value()
+		as.Nil(err)
+		as.Equal(`{"a",NULL,NULL,1,2.5,true,"2023-01-02T03:04:05Z","\\"}`, value)
+
+		_, err = postgresArray{struct{}{}}.Value()
+		as.Equal("gormx: struct {} can not be an element of postgres array", err.Error())
+	})
+
+	t.Run("bit and set", func(t *testing.T) {
+		query := struct {
+			ID  *int `gorm:"column:id"`
+			Tag *int `gorm:"column:tags; query_expr:find_in_set"`
+		}{ID: ptr(1), Tag: ptr(7)}
+		update := struct {
+			Toggle *int `gorm:"column:flags; update_expr:toggle"`
+			Clear  *int `gorm:"column:flags; update_expr:bit_clear"`
+		}{Toggle: ptr(1), Clear: ptr(4)}
+		tests := []struct {
+			dialect string
+			sql     string
+		}{
+			{"postgres", `UPDATE "user" SET "flags"=("flags" # 1) & -5 WHERE ("id" = 1 AND '7' = ANY(string_to_array("tags", ',')))`},
+			{"sqlite", `UPDATE "user" SET "flags"=(("flags" | 1) - ("flags" & 1)) & -5 WHERE ("id" = 1 AND instr(',' || "tags" || ',', ',' || '7' || ',') > 0)`},
+			{"sqlserver", `UPDATE [user] SET [flags]=([flags] ^ 1) & -5 WHERE ([id] = 1 AND '7' IN (SELECT value FROM STRING_SPLIT([tags], ',')))`},
+		}
+		for _, tt := range tests {
+			db := newDialectDB(tt.dialect)
+			sql := db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+				return tx.Table("user").Where(Query(query)).Updates(Update(update))
+			})
+			as.Equal(tt.sql, sql, tt.dialect)
+		}
+	})
+
+	t.Run("null safe", func(t *testing.T) {
+		query := struct {
+			ParentID **int  `gorm:"column:parent_id; query_expr:<=>"`
+			OwnerID  *int   `gorm:"column:owner_id; query_expr:<=>"`
+			Status   **int  `gorm:"column:status; query_expr:is_distinct"`
+			Level    *int64 `gorm:"column:level; query_expr:is_distinct"`
+		}{
+			ParentID: ptr((*int)(nil)),
+			OwnerID:  ptr(3),
+			Status:   ptr((*int)(nil)),
+			Level:    ptr(int64(2)),
+		}
+		tests := []struct {
+			dialect string
+			sql     string
+		}{
+			{"postgres", `SELECT * FROM "user" WHERE ("parent_id" IS NOT DISTINCT FROM NULL AND "owner_id" IS NOT DISTINCT FROM 3 AND "status" IS DISTINCT FROM NULL AND "level" IS DISTINCT FROM 2)`},
+			{"sqlite", `SELECT * FROM "user" WHERE ("parent_id" IS NULL AND "owner_id" IS 3 AND "status" IS NOT NULL AND "level" IS NOT 2)`},
+			{"sqlserver", `SELECT * FROM [user] WHERE ([parent_id] IS NULL AND [owner_id] = 3 AND [status] IS NOT NULL AND ([level] <> 2 OR [level] IS NULL))`},
+		}
+		for _, tt := range tests {
+			db := newDialectDB(tt.dialect)
+			sql := db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+				return tx.Where(Query(query)).Find(&[]User{})
+			})
+			as.Equal(tt.sql, sql, tt.dialect)
+		}
+	})
+
+	t.Run("func", func(t *testing.T) {
+		query := struct {
+			Email *string    `gorm:"column:email; func:lower"`
+			Name  *int       `gorm:"column:name; query_expr:>; func:length"`
+			Day   *time.Time `gorm:"column:created_at; func:date"`
+		}{
+			Email: ptr("A@B.com"),
+			Name:  ptr(3),
+			Day:   ptr(time.Date(2023, 5, 6, 12, 0, 0, 0, time.UTC)),
+		}
+		tests := []struct {
+			dialect string
+			sql     string
+		}{
+			{"postgres", `SELECT * FROM "user" WHERE (LOWER("email") = 'a@b.com' AND LENGTH("name") > 3 AND CAST("created_at" AS DATE) = '2023-05-06')`},
+			{"sqlite", `SELECT * FROM "user" WHERE (LOWER("email") = 'a@b.com' AND LENGTH("name") > 3 AND DATE("created_at") = '2023-05-06')`},
+			{"sqlserver", `SELECT * FROM [user] WHERE (LOWER([email]) = 'a@b.com' AND LEN([name]) > 3 AND CAST([created_at] AS DATE) = '2023-05-06')`},
+		}
+		for _, tt := range tests {
+			db := newDialectDB(tt.dialect)
+			sql := db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+				return tx.Where(Query(query)).Find(&[]User{})
+			})
+			as.Equal(tt.sql, sql, tt.dialect)
+		}
+	})
+
+	t.Run("search", func(t *testing.T) {
+		query := struct {
+			Keyword *string `gorm:"query_expr:search; columns:name,email; ignore_case"`
+		}{
+			Keyword: ptr("Bob"),
+		}
+		tests := []struct {
+			dialect string
+			sql     string
+		}{
+			{"postgres", `SELECT * FROM "user" WHERE ("name" ILIKE '%Bob%' ESCAPE '\' OR "email" ILIKE '%Bob%' ESCAPE '\')`},
+			{"sqlite", `SELECT * FROM "user" WHERE (LOWER("name") LIKE '%bob%' ESCAPE '\' OR LOWER("email") LIKE '%bob%' ESCAPE '\')`},
+			{"sqlserver", `SELECT * FROM [user] WHERE (LOWER([name]) LIKE '%bob%' ESCAPE '\' OR LOWER([email]) LIKE '%bob%' ESCAPE '\')`},
+		}
+		for _, tt := range tests {
+			db := newDialectDB(tt.dialect)
+			sql := db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+				return tx.Where(Query(query)).Find(&[]User{})
+			})
+			as.Equal(tt.sql, sql, tt.dialect)
+		}
+	})
+
+	t.Run("geo", func(t *testing.T) {
+		query := struct {
+			Near *GeoRadius `gorm:"column:location; query_expr:within_radius"`
+			Box  *GeoBox    `gorm:"column:location; query_expr:within_box"`
+		}{
+			Near: &GeoRadius{Lat: 1.5, Lng: 2.5, Meters: 100},
+			Box:  &GeoBox{MinLat: 1, MinLng: 2, MaxLat: 3, MaxLng: 4},
+		}
+		db := newDialectDB("postgres")
+		sql := db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+			return tx.Where(Query(query)).Find(&[]User{})
+		})
+		as.Equal(`SELECT * FROM "user" WHERE (ST_DWithin("location"::geography, ST_SetSRID(ST_MakePoint(2.500000, 1.500000), 4326)::geography, 100.000000) AND "location" && ST_MakeEnvelope(2.000000, 1.000000, 4.000000, 3.000000, 4326))`, sql)
+
+		db = newDialectDB("sqlserver")
+		sql = db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+			return tx.Where(Query(struct {
+				Near *GeoRadius `gorm:"column:location; query_expr:within_radius"`
+			}{Near: query.Near})).Find(&[]User{})
+		})
+		as.Equal(`SELECT * FROM [user] WHERE [location].STDistance(geography::Point(1.500000, 2.500000, 4326)) <= 100.000000`, sql)
+
+		cross := struct {
+			Box *GeoBox `gorm:"column:location; query_expr:within_box"`
+		}{
+			Box: &GeoBox{MinLat: 10, MinLng: 170, MaxLat: 20, MaxLng: -170},
+		}
+		db = newDialectDB("postgres")
+		sql = db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+			return tx.Where(Query(cross)).Find(&[]User{})
+		})
+		as.Equal(`SELECT * FROM "user" WHERE ("location" && ST_MakeEnvelope(170.000000, 10.000000, 180.000000, 20.000000, 4326) OR `+
+			`"location" && ST_MakeEnvelope(-180.000000, 10.000000, -170.000000, 20.000000, 4326))`, sql)
+
+		db = newDialectDB("mysql")
+		sql = db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+			return tx.Where(Query(cross)).Find(&[]User{})
+		})
+		as.Equal("SELECT * FROM `user` WHERE (MBRContains(ST_GeomFromText('POLYGON((170 10,180 10,180 20,170 20,170 10))'), `location`) OR "+
+			"MBRContains(ST_GeomFromText('POLYGON((-180 10,-170 10,-170 20,-180 20,-180 10))'), `location`))", sql)
+
+		err := newDialectDB("sqlite").Where(Query(query)).Find(&[]User{}).Error
+		as.True(errors.Is(err, ErrUnsupported))
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		db := newDialectDB("sqlserver")
+		err := db.Table("user").Where("id = ?", 1).Updates(Update(struct {
+			Data map[string]interface{} `gorm:"column:data; update_expr:merge_json"`
+		}{Data: map[string]interface{}{"a": 1}})).Error
+		as.NotNil(err)
+		as.True(errors.Is(err, ErrUnsupported))
+		as.Equal("gormx: operator not supported by dialect: merge_json on sqlserver", err.Error())
+	})
+
+	t.Run("not registered", func(t *testing.T) {
+		db := newDialectDB("clickhouse")
+		err := db.Where(Query(struct {
+			Name *string `gorm:"column:name; query_expr:contains"`
+		}{Name: ptr("a")})).Find(&[]User{}).Error
+		as.NotNil(err)
+		as.Equal("gormx: dialect clickhouse not registered", err.Error())
+	})
+
+	t.Run("custom", func(t *testing.T) {
+		mysql, _ := GetDialect("mysql")
+		RegisterDialect("tidb", tidbDialect{mysql})
+		defer dialectMap.Delete("tidb")
+
+		db := newDialectDB("tidb")
+		sql := db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+			return tx.Where(Query(struct {
+				Name *string `gorm:"column:name; query_expr:ends_with"`
+			}{Name: ptr("%")})).Find(&[]User{})
+		})
+		as.Equal(`SELECT * FROM "user" WHERE "name" LIKE '%\%' ESCAPE '\\'`, sql)
+	})
+}
+
+type tidbDialect struct {
+	Dialect
+}
+
+func (tidbDialect) Name() string {
+	return "tidb"
+}
@@ -0,0 +1,99 @@
+package gormx
+
+import (
+	"fmt"
+	"math"
+
+	"gorm.io/gorm/clause"
+)
+
+// earthRadius is the mean radius of the earth in meters
+const earthRadius = 6371008.8
+
+// GeoRadius is the value of a within_radius query_expr, the circle of Meters around Lat, Lng.
+type GeoRadius struct {
+	Lat    float64
+	Lng    float64
+	Meters float64
+}
+
+// GeoBox is the value of a within_box query_expr, MinLng greater than MaxLng crosses the antimeridian.
+type GeoBox struct {
+	MinLat float64
+	MinLng float64
+	MaxLat float64
+	MaxLng float64
+}
+
+func (r GeoRadius) check() error {
+	if r.Lat < -90 || r.Lat > 90 || r.Lng < -180 || r.Lng > 180 {
+		return fmt.Errorf("geo radius center(%v, %v) invalid", r.Lat, r.Lng)
+	}
+	if r.Meters < 0 {
+		return fmt.Errorf("geo radius meters(%v) invalid", r.Meters)
+	}
+	return nil
+}
+
+func (b GeoBox) check() error {
+	if b.MinLat < -90 || b.MaxLat > 90 || b.MinLat > b.MaxLat || b.MinLng < -180 || b.MaxLng > 180 {
+		return fmt.Errorf("geo box(%v, %v, %v, %v) invalid", b.MinLat, b.MinLng, b.MaxLat, b.MaxLng)
+	}
+	return nil
+}
+
+// polygon is the WKT of the box in lng lat order
+func (b GeoBox) polygon() string {
+	return fmt.Sprintf("POLYGON((%[2]v %[1]v,%[4]v %[1]v,%[4]v %[3]v,%[2]v %[3]v,%[2]v %[1]v))", b.MinLat, b.MinLng, b.MaxLat, b.MaxLng)
+}
+
+// split is the box, or its two halves on both sides of the antimeridian when it crosses it,
+// a spatial envelope from MinLng to MaxLng would be the complement of the box
+func (b GeoBox) split() []GeoBox {
+	if b.MinLng <= b.MaxLng {
+		return []GeoBox{b}
+	}
+	east, west := b, b
+	east.MaxLng, west.MinLng = 180, -180
+	return []GeoBox{east, west}
+}
+
+// bound is the box around the circle, it lets the index of lat/lng narrow the rows before haversine
+func (r GeoRadius) bound() GeoBox {
+	distance := r.Meters / earthRadius
+	dLat := distance * 180 / math.Pi
+	box := GeoBox{MinLat: r.Lat - dLat, MaxLat: r.Lat + dLat, MinLng: -180, MaxLng: 180}
+	if box.MinLat <= -90 || box.MaxLat >= 90 {
+		// a pole is in the circle, every longitude is
+		box.MinLat, box.MaxLat = math.Max(box.MinLat, -90), math.Min(box.MaxLat, 90)
+		return box
+	}
+	dLng := math.Asin(math.Sin(distance)/math.Cos(r.Lat*math.Pi/180)) * 180 / math.Pi
+	box.MinLng, box.MaxLng = r.Lng-dLng, r.Lng+dLng
+	// across the antimeridian, MinLng ends up greater than MaxLng
+	if box.MinLng < -180 {
+		box.MinLng += 360
+	} else if box.MaxLng > 180 {
+		box.MaxLng -= 360
+	}
+	return box
+}
+
+// latLngBox is the condition of box on the lat and lng columns
+func latLngBox(lat, lng clause.Column, box GeoBox) clause.Expression {
+	latRange := between{Column: lat, From: box.MinLat, To: box.MaxLat}
+	if box.MinLng > box.MaxLng {
+		return clause.And(latRange, clause.Or(clause.Gte{Column: lng, Value: box.MinLng}, clause.Lte{Column: lng, Value: box.MaxLng}))
+	}
+	return clause.And(latRange, between{Column: lng, From: box.MinLng, To: box.MaxLng})
+}
+
+// latLngRadius is the bounding box and haversine distance of radius on the lat and lng columns
+func latLngRadius(lat, lng clause.Column, radius GeoRadius) clause.Expression {
+	box := radius.bound()
+	haversine := clause.Expr{
+		SQL:  "? * 2 * ASIN(SQRT(POWER(SIN(RADIANS(? - ?) / 2), 2) + COS(RADIANS(?)) * COS(RADIANS(?)) * POWER(SIN(RADIANS(? - ?) / 2), 2))) <= ?",
+		Vars: []interface{}{earthRadius, lat, radius.Lat, radius.Lat, lat, lng, radius.Lng, radius.Meters},
+	}
+	return clause.And(latLngBox(lat, lng, box), haversine)
+}
@@ -0,0 +1,50 @@
+package gormx
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGeoRadius_bound(t *testing.T) {
+	as := assert.New(t)
+
+	t.Run("equator", func(t *testing.T) {
+		b := GeoRadius{Lat: 0, Lng: 10, Meters: 111195}.bound()
+		as.InDelta(-1, b.MinLat, 1e-3)
+		as.InDelta(1, b.MaxLat, 1e-3)
+		as.InDelta(9, b.MinLng, 1e-3)
+		as.InDelta(11, b.MaxLng, 1e-3)
+	})
+
+	t.Run("antimeridian", func(t *testing.T) {
+		b := GeoRadius{Lat: 0, Lng: 179.5, Meters: 111195}.bound()
+		as.InDelta(178.5, b.MinLng, 1e-3)
+		as.InDelta(-179.5, b.MaxLng, 1e-3)
+		as.Greater(b.MinLng, b.MaxLng)
+	})
+
+	t.Run("pole", func(t *testing.T) {
+		b := GeoRadius{Lat: 89.5, Lng: 0, Meters: 111195}.bound()
+		as.InDelta(88.5, b.MinLat, 1e-3)
+		as.Equal(90.0, b.MaxLat)
+		as.Equal(-180.0, b.MinLng)
+		as.Equal(180.0, b.MaxLng)
+	})
+}
+
+func TestGeoBox_polygon(t *testing.T) {
+	as := assert.New(t)
+	as.Equal("POLYGON((2 1,4 1,4 3,2 3,2 1))", GeoBox{MinLat: 1, MinLng: 2, MaxLat: 3, MaxLng: 4}.polygon())
+	as.NotNil(GeoBox{MinLat: 3, MaxLat: 1}.check())
+}
+
+func TestGeoBox_split(t *testing.T) {
+	as := assert.New(t)
+	box := GeoBox{MinLat: 1, MinLng: 2, MaxLat: 3, MaxLng: 4}
+	as.Equal([]GeoBox{box}, box.split())
+	as.Equal([]GeoBox{
+		{MinLat: 10, MinLng: 170, MaxLat: 20, MaxLng: 180},
+		{MinLat: 10, MinLng: -180, MaxLat: 20, MaxLng: -170},
+	}, GeoBox{MinLat: 10, MinLng: 170, MaxLat: 20, MaxLng: -170}.split())
+}
@@ -0,0 +1,151 @@
+package gormx
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// JSONPath is a parsed json path like $.profile.tags[0], its elements are
+// string keys and int array indexes.
+type JSONPath []interface{}
+
+func parseJSONPath(s string) (JSONPath, error) {
+	if !strings.HasPrefix(s, "$") {
+		return nil, fmt.Errorf("json path(%s) must start with $", s)
+	}
+
+	var path JSONPath
+	for rest := s[1:]; rest != ""; {
+		switch rest[0] {
+		case '.':
+			if strings.HasPrefix(rest, `."`) {
+				key, n, err := unquoteJSONPathKey(rest[1:])
+				if err != nil {
+					return nil, fmt.Errorf("json path(%s) has invalid key: %w", s, err)
+				}
+				path = append(path, key)
+				rest = rest[1+n:]
+				continue
+			}
+			end := 1
+			for end < len(rest) && isJSONPathKeyChar(rest[end]) {
+				end++
+			}
+			if end == 1 {
+				return nil, fmt.Errorf("json path(%s) has invalid key", s)
+			}
+			path = append(path, rest[1:end])
+			rest = rest[end:]
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("json path(%s) has unclosed [", s)
+			}
+			idx, err := strconv.Atoi(rest[1:end])
+			if err != nil || idx < 0 {
+				return nil, fmt.Errorf("json path(%s) has invalid index %s", s, rest[1:end])
+			}
+			path = append(path, idx)
+			rest = rest[end+1:]
+		default:
+			return nil, fmt.Errorf("json path(%s) has invalid character %q", s, rest[0])
+		}
+	}
+	if len(path) == 0 {
+		return nil, fmt.Errorf("json path(%s) must point into the document", s)
+	}
+	return path, nil
+}
+
+// unquoteJSONPathKey unquotes the "key" at the start of s, n is the length of the quoted key
+func unquoteJSONPathKey(s string) (key string, n int, err error) {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			key, err = strconv.Unquote(s[:i+1])
+			return key, i + 1, err
+		}
+	}
+	return "", 0, fmt.Errorf("unclosed quote")
+}
+
+func isJSONPathKey(key string) bool {
+	for i := 0; i < len(key); i++ {
+		if !isJSONPathKeyChar(key[i]) {
+			return false
+		}
+	}
+	return key != ""
+}
+
+func isJSONPathKeyChar(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// String returns the path in the $.key[0] syntax of mysql, sqlite and sqlserver
+func (p JSONPath) String() string {
+	var sb strings.Builder
+	sb.WriteByte('$')
+	for _, elem := range p {
+		switch v := elem.(type) {
+		case int:
+			sb.WriteString("[" + strconv.Itoa(v) + "]")
+		default:
+			key := fmt.Sprint(v)
+			if !isJSONPathKey(key) {
+				key = strconv.Quote(key)
+			}
+			sb.WriteString("." + key)
+		}
+	}
+	return sb.String()
+}
+
+// Array returns the path as a postgres text[] literal like {key,0}
+func (p JSONPath) Array() string {
+	elems := make([]string, 0, len(p))
+	for _, elem := range p {
+		key := fmt.Sprint(elem)
+		if !isJSONPathKey(key) {
+			key = `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(key) + `"`
+		}
+		elems = append(elems, key)
+	}
+	return "{" + strings.Join(elems, ",") + "}"
+}
+
+// jsonValueType is the type data is compared as with a value in a json document
+func jsonValueType(data interface{}) string {
+	rt := reflect.TypeOf(data)
+	if rt == nil {
+		return jsonTypeText
+	}
+	for rt.Kind() == reflect.Ptr || rt.Kind() == reflect.Slice || rt.Kind() == reflect.Array {
+		rt = rt.Elem()
+	}
+	if rt.Implements(rangeExpressionType) {
+		if from, ok := rt.FieldByName("From"); ok {
+			rt = from.Type.Elem()
+		}
+	}
+
+	switch rt.Kind() {
+	case reflect.Bool:
+		return jsonTypeBoolean
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return jsonTypeNumber
+	}
+	return jsonTypeText
+}
+
+const (
+	jsonTypeText    = "text"
+	jsonTypeNumber  = "number"
+	jsonTypeBoolean = "boolean"
+)
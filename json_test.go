@@ -0,0 +1,40 @@
+package gormx
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_parseJSONPath(t *testing.T) {
+	tests := []struct {
+		path  string
+		want  JSONPath
+		array string
+		err   string
+	}{
+		{"$.profile.city", JSONPath{"profile", "city"}, "{profile,city}", ""},
+		{"$.tags[0]", JSONPath{"tags", 0}, "{tags,0}", ""},
+		{"$[1].a_b", JSONPath{1, "a_b"}, "{1,a_b}", ""},
+		{`$."first name".x`, JSONPath{"first name", "x"}, `{"first name",x}`, ""},
+		{"profile", nil, "", "json path(profile) must start with $"},
+		{"$", nil, "", "json path($) must point into the document"},
+		{"$.", nil, "", "json path($.) has invalid key"},
+		{"$.a b", nil, "", `json path($.a b) has invalid character ' '`},
+		{"$.a[x]", nil, "", "json path($.a[x]) has invalid index x"},
+		{"$.a[0", nil, "", "json path($.a[0) has unclosed ["},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := parseJSONPath(tt.path)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.path, got.String())
+			assert.Equal(t, tt.array, got.Array())
+		})
+	}
+}
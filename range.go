@@ -0,0 +1,53 @@
+package gormx
+
+import (
+	"reflect"
+
+	"gorm.io/gorm/clause"
+)
+
+// Range is the value of a between query_expr, a nil bound leaves that side open.
+type Range[T any] struct {
+	From          *T
+	To            *T
+	ExclusiveFrom bool
+	ExclusiveTo   bool
+}
+
+// rangeExpression is implemented by every Range[T]
+type rangeExpression interface {
+	rangeExpression(column interface{}) clause.Expression
+}
+
+var rangeExpressionType = reflect.TypeOf((*rangeExpression)(nil)).Elem()
+
+func (r Range[T]) rangeExpression(column interface{}) clause.Expression {
+	var from, to clause.Expression
+	if r.From != nil {
+		if r.ExclusiveFrom {
+			from = clause.Gt{Column: column, Value: *r.From}
+		} else {
+			from = clause.Gte{Column: column, Value: *r.From}
+		}
+	}
+	if r.To != nil {
+		if r.ExclusiveTo {
+			to = clause.Lt{Column: column, Value: *r.To}
+		} else {
+			to = clause.Lte{Column: column, Value: *r.To}
+		}
+	}
+
+	switch {
+	case from != nil && to != nil:
+		if !r.ExclusiveFrom && !r.ExclusiveTo {
+			return between{Column: column, From: *r.From, To: *r.To}
+		}
+		return clause.And(from, to)
+	case from != nil:
+		return from
+	case to != nil:
+		return to
+	}
+	return nil
+}
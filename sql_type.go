@@ -3,6 +3,8 @@ package gormx
 import (
 	"fmt"
 	"reflect"
+	"strconv"
+	"strings"
 	"sync"
 
 	"gorm.io/gorm/schema"
@@ -12,6 +14,49 @@ const (
 	tagColumn = "COLUMN"
 	tagQuery  = "QUERY_EXPR"
 	tagUpdate = "UPDATE_EXPR"
+	tagPath   = "PATH"
+
+	// options of fulltext query_expr
+	tagColumns = "COLUMNS"
+	tagMode    = "MODE"
+	tagOrder   = "ORDER"
+
+	// flag of regexp query_expr
+	tagIgnoreCase = "IGNORE_CASE"
+
+	// options of in_subquery query_expr
+	tagTable  = "TABLE"
+	tagSelect = "SELECT"
+
+	// option of has and has_count query_expr
+	tagAssociation = "ASSOCIATION"
+
+	// option of same_day, same_month and within query_expr
+	tagTZ = "TZ"
+
+	// option of within_radius and within_box query_expr, columns is lat,lng
+	tagSRID = "SRID"
+
+	// option of compare query_expr, the column compared with
+	tagRefColumn = "REF_COLUMN"
+
+	// SQL function wrapping the column, one of lower, upper, trim, length and date
+	tagFunc = "FUNC"
+
+	// template of raw query_expr, also read from the sql struct tag
+	tagSQL = "SQL"
+
+	// fields of the same group are joined by group_join, or and and, in parentheses
+	tagGroup     = "GROUP"
+	tagGroupJoin = "GROUP_JOIN"
+
+	// like query_expr on a slice matches any or all of its strings
+	tagQuantifier = "QUANTIFIER"
+
+	// the null query_expr on a column with this suffix checks the column without it, whether
+	// the query_expr is tagged or chosen by the bool type, deleted_at_null is deleted_at IS NULL.
+	// update_expr keeps the column as it is
+	nullColumnSuffix = "_null"
 )
 
 var structTypeCacheMap sync.Map
@@ -19,17 +64,24 @@ var structTypeCacheMap sync.Map
 type structType struct {
 	Names  []string
 	Fields map[string]*fieldType
+	Groups map[string]string // key: tag group, value: tag group_join
+	Table  string            // qualifies the columns, the alias of the association of query_expr has
 }
 
 type fieldType struct {
 	Name        string            // field name
 	Column      string            // tag sql_field
 	QueryExpr   string            // tag query_expr
+	QueryByType bool              // QueryExpr 没有 tag，由字段类型决定
 	UpdateExpr  string            // tag update_expr
 	IsAnonymous bool              // field 是否是匿名字段
 	Kind        reflect.Kind      // field Kind
 	OrType      reflect.Type      // field OrType
+	NestedType  reflect.Type      // struct of query_expr json, in_subquery and has
 	Tag         map[string]string // key: COLUMN etc.
+
+	JSONColumn string   // column of the json document, the field is in a query_expr json struct
+	JSONPath   JSONPath // path of the field in the json document
 }
 
 func parseStructType(t reflect.Type) (*structType, error) {
@@ -67,13 +119,22 @@ func parseStructTypeRev(t reflect.Type, sType *structType, isField bool) (_ *str
 		structField := t.Field(i)
 		tag := schema.ParseTagSetting(structField.Tag.Get("gorm"), ";")
 		columnName := tag[tagColumn]
-		queryExprString := tag[tagQuery]
-		updateExprString := tag[tagUpdate]
+		if isColumnEmpty(columnName) && tag[tagColumns] != "" {
+			// 没有 column 时用 columns 的第一列
+			columnName = columnsOf("", tag)[0]
+		}
+		queryExprString := resolveQueryExpr(tag[tagQuery])
+		baseQueryExpr, _ := negatedQueryExpr(queryExprString)
+		if sql, ok := structField.Tag.Lookup("sql"); ok && baseQueryExpr == operatorRaw && tag[tagSQL] == "" {
+			// sql:"..." 可以包含 gorm tag 的分隔符，其他 query_expr 的 sql tag 是旧的 gorm tag，忽略
+			tag[tagSQL] = sql
+		}
+		updateExprString := resolveUpdateExpr(tag[tagUpdate])
 		ft := structField.Type
 		if ft.Kind() == reflect.Ptr {
 			ft = ft.Elem()
 		}
-		isOr := isColumnEmpty(columnName) && queryExprString == operatorOr
+		isOr := isColumnEmpty(columnName) && (queryExprString == operatorOr || queryExprString == operatorNot)
 		if isColumnEmpty(columnName) {
 			if structField.Anonymous {
 				// 匿名字段，不跳过
@@ -82,7 +143,14 @@ func parseStructTypeRev(t reflect.Type, sType *structType, isField bool) (_ *str
 				continue
 			}
 		}
-		if err := checkField(structField, columnName, queryExprString, ft); err != nil {
+		queryByType := false
+		if queryExprString == "" && updateExprString == "" && !structField.Anonymous && !isColumnEmpty(columnName) {
+			if _, ok := tag[tagRefColumn]; !ok {
+				queryExprString = defaultQueryExpr(structField.Type, columnName)
+				baseQueryExpr, queryByType = queryExprString, queryExprString != ""
+			}
+		}
+		if err := checkField(structField, columnName, baseQueryExpr, ft, tag); err != nil {
 			return nil, err
 		}
 
@@ -92,21 +160,51 @@ func parseStructTypeRev(t reflect.Type, sType *structType, isField bool) (_ *str
 				return nil, err
 			}
 		} else {
-			var fieldStructType reflect.Type
+			var fieldStructType, nestedType reflect.Type
 			if isOr {
 				if ft.Kind() == reflect.Slice {
 					ft = ft.Elem()
 				}
 				fieldStructType = ft
+			} else if baseQueryExpr == operatorJSON || baseQueryExpr == operatorInSubquery || baseQueryExpr == operatorHas {
+				nestedType = ft
 			}
-			if err := parseNormalStructField(structField, queryExprString, updateExprString, columnName, tag, fieldStructType, sType); err != nil {
+			if err := parseNormalStructField(structField, queryExprString, updateExprString, columnName, tag, fieldStructType, nestedType, sType); err != nil {
 				return nil, err
 			}
+			sType.Fields[structField.Name].QueryByType = queryByType
 		}
 	}
 	return sType, nil
 }
 
+// defaultQueryExpr is the operator of a field without query_expr and update_expr, chosen
+// by the type of the field, "" is =
+func defaultQueryExpr(t reflect.Type, columnName string) string {
+	if t == gormDBType {
+		return operatorIn
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch {
+	case t.Implements(rangeExpressionType):
+		return operatorBetween
+	case t == reflect.TypeOf(GeoRadius{}):
+		return operatorWithinRadius
+	case t == reflect.TypeOf(GeoBox{}):
+		return operatorWithinBox
+	case (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) && t.Elem().Kind() == reflect.Uint8:
+		// []byte 和 json.RawMessage 等是一个值
+		return ""
+	case t.Kind() == reflect.Slice || t.Kind() == reflect.Array:
+		return operatorIn
+	case t.Kind() == reflect.Bool && isNullColumn(columnName):
+		return operatorNull
+	}
+	return ""
+}
+
 func reOrderNames(sType *structType, name string) {
 	for idx, n := range sType.Names {
 		if n == name {
@@ -117,7 +215,7 @@ func reOrderNames(sType *structType, name string) {
 	}
 }
 
-func checkField(structField reflect.StructField, columnName, queryExprString string, ft reflect.Type) error {
+func checkField(structField reflect.StructField, columnName, queryExprString string, ft reflect.Type, tag map[string]string) error {
 	// 匿名
 	if structField.Anonymous {
 		if !isColumnEmpty(columnName) {
@@ -140,9 +238,19 @@ func checkField(structField reflect.StructField, columnName, queryExprString str
 		}
 	}
 
+	// not
+	if queryExprString == operatorNot {
+		if !isColumnEmpty(columnName) {
+			return fmt.Errorf("struct field(%s) with query_expr(%s) cannot set column tag", structField.Name, queryExprString)
+		}
+		if ft.Kind() != reflect.Struct {
+			return fmt.Errorf("struct field(%s) with query_expr(%s) must be struct", structField.Name, queryExprString)
+		}
+	}
+
 	// 非匿名
 	if !structField.Anonymous {
-		if isColumnEmpty(columnName) && queryExprString != operatorOr {
+		if isColumnEmpty(columnName) && !isColumnFree(queryExprString) {
 			return fmt.Errorf("struct field(%s) need column tag", structField.Name)
 		}
 	}
@@ -153,35 +261,272 @@ func checkField(structField reflect.StructField, columnName, queryExprString str
 		rt = rt.Elem()
 	}
 	switch queryExprString {
-	case operatorIn:
+	case operatorIn, operatorNin:
+		if structField.Type == gormDBType {
+			break
+		}
+		if rt.Kind() != reflect.Slice && rt.Kind() != reflect.Array {
+			return fmt.Errorf("struct field(%s) with %s query_expr must be slice/array", structField.Name, queryExprString)
+		}
+	case operatorArrayContains, operatorArrayOverlaps, operatorArrayContainedBy:
 		if rt.Kind() != reflect.Slice && rt.Kind() != reflect.Array {
-			return fmt.Errorf("struct field(%s) with in query_expr must be slice/array", structField.Name)
+			return fmt.Errorf("struct field(%s) with %s query_expr must be slice/array", structField.Name, queryExprString)
 		}
 	case operatorEq:
 		if rt.Kind() == reflect.Slice || rt.Kind() == reflect.Array {
 			return fmt.Errorf("struct field(%s) with eq query_expr can not be slice/array", structField.Name)
 		}
+	case operatorNullSafeEq, operatorIsDistinct:
+		for rt.Kind() == reflect.Ptr {
+			rt = rt.Elem()
+		}
+		if rt.Kind() == reflect.Slice || rt.Kind() == reflect.Array {
+			return fmt.Errorf("struct field(%s) with %s query_expr can not be slice/array", structField.Name, queryExprString)
+		}
+	case operatorBitsAll, operatorBitsAny, operatorBitsNone:
+		if !isIntegerKind(rt.Kind()) {
+			return fmt.Errorf("struct field(%s) with %s query_expr must be integer", structField.Name, queryExprString)
+		}
+	case operatorFindInSet:
+		if rt.Kind() != reflect.String && !isIntegerKind(rt.Kind()) {
+			return fmt.Errorf("struct field(%s) with find_in_set query_expr must be string or integer", structField.Name)
+		}
+	case operatorAny:
+		if rt.Kind() == reflect.Slice || rt.Kind() == reflect.Array {
+			return fmt.Errorf("struct field(%s) with any query_expr can not be slice/array", structField.Name)
+		}
+	case operatorLike, operatorStartsWith, operatorEndsWith, operatorContains:
+		if rt.Kind() != reflect.Slice && rt.Kind() != reflect.Array {
+			if rt.Kind() != reflect.String {
+				return fmt.Errorf("struct field(%s) with %s query_expr must be string or it's list", structField.Name, queryExprString)
+			}
+			if _, ok := tag[tagQuantifier]; ok {
+				return fmt.Errorf("struct field(%s) with quantifier tag must be slice/array", structField.Name)
+			}
+			break
+		}
+		elem := rt.Elem()
+		if elem.Kind() == reflect.Ptr {
+			elem = elem.Elem()
+		}
+		if elem.Kind() != reflect.String {
+			return fmt.Errorf("struct field(%s) with %s query_expr must be string or it's list", structField.Name, queryExprString)
+		}
+		if quantifier := tag[tagQuantifier]; quantifier != quantifierAny && quantifier != quantifierAll {
+			return fmt.Errorf("struct field(%s) with %s query_expr of slice/array need quantifier tag any or all", structField.Name, queryExprString)
+		}
+	case operatorFullText, operatorRegexp, operatorNotRegexp, operatorSearch:
+		if rt.Kind() != reflect.String {
+			return fmt.Errorf("struct field(%s) with %s query_expr must be string", structField.Name, queryExprString)
+		}
+	case operatorJSON, operatorInSubquery, operatorHas:
+		if rt.Kind() != reflect.Struct {
+			return fmt.Errorf("struct field(%s) with %s query_expr must be struct", structField.Name, queryExprString)
+		}
+	case operatorHasCount:
+		if !rt.Implements(rangeExpressionType) && !isIntegerKind(rt.Kind()) {
+			return fmt.Errorf("struct field(%s) with has_count query_expr must be integer or gormx.Range", structField.Name)
+		}
+	case operatorSameDay, operatorSameMonth:
+		if rt != timeType {
+			return fmt.Errorf("struct field(%s) with %s query_expr must be time.Time", structField.Name, queryExprString)
+		}
+	case operatorWithin:
+		if rt != durationType {
+			return fmt.Errorf("struct field(%s) with within query_expr must be time.Duration", structField.Name)
+		}
+	case operatorWithinRadius:
+		if rt != reflect.TypeOf(GeoRadius{}) {
+			return fmt.Errorf("struct field(%s) with within_radius query_expr must be gormx.GeoRadius", structField.Name)
+		}
+	case operatorWithinBox:
+		if rt != reflect.TypeOf(GeoBox{}) {
+			return fmt.Errorf("struct field(%s) with within_box query_expr must be gormx.GeoBox", structField.Name)
+		}
+	case operatorExists, operatorNotExists:
+		if structField.Type != gormDBType {
+			return fmt.Errorf("struct field(%s) with %s query_expr must be *gorm.DB", structField.Name, queryExprString)
+		}
+	case operatorBetween:
+		if !rt.Implements(rangeExpressionType) {
+			return fmt.Errorf("struct field(%s) with between query_expr must be gormx.Range", structField.Name)
+		}
 	}
 
 	return nil
 }
 
-func checkQueryExpr(field reflect.StructField, q string) error {
+func checkQueryExpr(field reflect.StructField, q string, tag map[string]string) error {
+	var expr queryExpr
 	if q != "" {
-		if _, ok := queryExprMap[q]; !ok {
+		var ok bool
+		if expr, ok = lookupQueryExpr(q); !ok {
 			return fmt.Errorf("field(%s) query_expr(%s) invalid", field.Name, q)
 		}
 	}
+	// ! 前缀的 operator 和原 operator 的 tag 一样
+	q, _ = negatedQueryExpr(q)
+
+	if ref, ok := tag[tagRefColumn]; ok {
+		switch q {
+		case "", operatorEq, operatorNeq, operatorGt, operatorGte, operatorLt, operatorLte,
+			operatorColEq, operatorColNeq, operatorColGt, operatorColGte, operatorColLt, operatorColLte:
+		default:
+			return fmt.Errorf("field(%s) query_expr(%s) can not have ref_column tag", field.Name, q)
+		}
+		if isColumnEmpty(ref) {
+			return fmt.Errorf("field(%s) query_expr(%s) ref_column tag invalid", field.Name, q)
+		}
+		rt := field.Type
+		if rt.Kind() == reflect.Ptr {
+			rt = rt.Elem()
+		}
+		if rt.Kind() != reflect.Bool {
+			return fmt.Errorf("struct field(%s) with ref_column tag must be bool", field.Name)
+		}
+	}
+
+	if name, ok := tag[tagFunc]; ok {
+		switch strings.ToLower(name) {
+		case funcLower, funcUpper, funcTrim, funcLength, funcDate:
+		default:
+			return fmt.Errorf("field(%s) query_expr(%s) func(%s) not supported", field.Name, q, name)
+		}
+		switch {
+		case isColumnFree(q), q == operatorJSON, q == operatorJSONContains, q == operatorInSubquery, q == operatorFullText, q == operatorSearch,
+			q == operatorWithinRadius, q == operatorWithinBox,
+			q == operatorArrayContains, q == operatorArrayOverlaps, q == operatorArrayContainedBy, q == operatorAny,
+			expr.custom:
+			// RegisterQueryExpr 的 builder 只拿到 column 名，func 包不到 column 上
+			return fmt.Errorf("field(%s) query_expr(%s) can not have func tag", field.Name, q)
+		}
+	}
+
+	if group, ok := tag[tagGroup]; ok && strings.TrimSpace(group) == "" {
+		return fmt.Errorf("field(%s) query_expr(%s) group tag invalid", field.Name, q)
+	}
+	if join, ok := tag[tagGroupJoin]; ok {
+		if _, ok := tag[tagGroup]; !ok {
+			return fmt.Errorf("field(%s) query_expr(%s) need group tag", field.Name, q)
+		}
+		if join = strings.ToLower(join); join != groupJoinOr && join != groupJoinAnd {
+			return fmt.Errorf("field(%s) query_expr(%s) group_join(%s) must be or or and", field.Name, q, join)
+		}
+	}
+
+	if _, ok := tag[tagQuantifier]; ok {
+		switch q {
+		case operatorLike, operatorStartsWith, operatorEndsWith, operatorContains:
+		default:
+			return fmt.Errorf("field(%s) query_expr(%s) can not have quantifier tag", field.Name, q)
+		}
+	}
 
+	if _, ok := tag[tagSQL]; ok && q != operatorRaw {
+		return fmt.Errorf("field(%s) query_expr(%s) can not have sql tag", field.Name, q)
+	}
+
+	switch q {
+	case operatorRaw:
+		if err := checkRawSQL(tag[tagSQL]); err != nil {
+			return fmt.Errorf("field(%s) query_expr(%s) sql(%s) invalid: %w", field.Name, q, tag[tagSQL], err)
+		}
+	case operatorColEq, operatorColNeq, operatorColGt, operatorColGte, operatorColLt, operatorColLte:
+		if _, ok := tag[tagRefColumn]; !ok {
+			return fmt.Errorf("field(%s) query_expr(%s) need ref_column tag", field.Name, q)
+		}
+	case operatorJSON, operatorJSONContains:
+		if path, ok := tag[tagPath]; ok {
+			if _, err := parseJSONPath(path); err != nil {
+				return fmt.Errorf("field(%s) query_expr(%s) need valid path tag: %w", field.Name, q, err)
+			}
+		}
+		if q == operatorJSON {
+			if err := checkJSONStruct(field.Type, map[reflect.Type]bool{}); err != nil {
+				return err
+			}
+		}
+	case operatorSameDay, operatorSameMonth, operatorWithin:
+		if tz, ok := tag[tagTZ]; ok {
+			if _, err := loadLocation(tz); err != nil {
+				return fmt.Errorf("field(%s) query_expr(%s) tz(%s) invalid: %w", field.Name, q, tz, err)
+			}
+		}
+	case operatorWithinRadius, operatorWithinBox:
+		if columns, ok := tag[tagColumns]; ok {
+			names := columnsOf("", tag)
+			if len(names) != 2 || names[0] == "" || names[1] == "" {
+				return fmt.Errorf("field(%s) query_expr(%s) columns(%s) must be lat,lng", field.Name, q, columns)
+			}
+		}
+		if srid, ok := tag[tagSRID]; ok {
+			if _, err := strconv.Atoi(srid); err != nil {
+				return fmt.Errorf("field(%s) query_expr(%s) srid(%s) invalid", field.Name, q, srid)
+			}
+		}
+	case operatorInSubquery:
+		if isColumnEmpty(tag[tagTable]) || isColumnEmpty(tag[tagSelect]) {
+			return fmt.Errorf("field(%s) query_expr(%s) need table and select tag", field.Name, q)
+		}
+	case operatorFullText:
+		if mode, ok := tag[tagMode]; ok && mode != fullTextModeNatural && mode != fullTextModeBoolean {
+			return fmt.Errorf("field(%s) query_expr(%s) mode(%s) invalid, must be natural or boolean", field.Name, q, mode)
+		}
+		if order, ok := tag[tagOrder]; ok && order != fullTextOrderRelevance {
+			return fmt.Errorf("field(%s) query_expr(%s) order(%s) invalid, must be relevance", field.Name, q, order)
+		}
+		if err := checkColumns(field, q, tag); err != nil {
+			return err
+		}
+	case operatorSearch:
+		if mode, ok := tag[tagMode]; ok && mode != searchModeTerms {
+			return fmt.Errorf("field(%s) query_expr(%s) mode(%s) invalid, must be terms", field.Name, q, mode)
+		}
+		if err := checkColumns(field, q, tag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkColumns(field reflect.StructField, q string, tag map[string]string) error {
+	if _, ok := tag[tagColumns]; ok {
+		for _, column := range columnsOf("", tag) {
+			if column == "" {
+				return fmt.Errorf("field(%s) query_expr(%s) columns(%s) has empty column", field.Name, q, tag[tagColumns])
+			}
+		}
+	}
 	return nil
 }
 
-func checkUpdateExpr(field reflect.StructField, q string) error {
+func checkUpdateExpr(field reflect.StructField, q string, tag map[string]string) error {
 	if q != "" {
-		if _, ok := updaterMap[q]; !ok {
+		if _, ok := lookupUpdateExpr(q); !ok {
 			return fmt.Errorf("field(%s) update_expr(%s) invalid", field.Name, q)
 		}
 	}
+
+	switch q {
+	case updateExprJSONSet, updateExprJSONRemove, updateExprJSONArrayAppend:
+		if _, err := parseJSONPath(tag[tagPath]); err != nil {
+			return fmt.Errorf("field(%s) update_expr(%s) need valid path tag: %w", field.Name, q, err)
+		}
+	}
+	rt := field.Type
+	if rt.Kind() == reflect.Ptr {
+		rt = rt.Elem()
+	}
+	switch q {
+	case updateExprJSONRemove:
+		if rt.Kind() != reflect.Bool {
+			return fmt.Errorf("struct field(%s) with json_remove update_expr must be bool", field.Name)
+		}
+	case updateExprBitSet, updateExprBitClear, updateExprToggle:
+		if !isIntegerKind(rt.Kind()) {
+			return fmt.Errorf("struct field(%s) with %s update_expr must be integer", field.Name, q)
+		}
+	}
 	return nil
 }
 
@@ -194,10 +539,146 @@ func loadStructTypeFromCache(t reflect.Type) *structType {
 	return nil
 }
 
+// withTable is the struct type whose columns are qualified with table, the cached type is not changed
+func (t *structType) withTable(table string) *structType {
+	if table == "" || t.Table == table {
+		return t
+	}
+	qualified := *t
+	qualified.Table = table
+	return &qualified
+}
+
+// withTable is a copy of the field whose columns are qualified with table
+func (f *fieldType) withTable(table string) *fieldType {
+	qualify := func(column string) string {
+		if isColumnEmpty(column) || strings.Contains(column, ".") {
+			return column
+		}
+		return table + "." + column
+	}
+	field := *f
+	field.Column = qualify(field.Column)
+	field.Tag = make(map[string]string, len(f.Tag))
+	for k, v := range f.Tag {
+		field.Tag[k] = v
+	}
+	if _, ok := field.Tag[tagColumns]; ok {
+		names := columnsOf("", field.Tag)
+		for i := range names {
+			names[i] = qualify(names[i])
+		}
+		field.Tag[tagColumns] = strings.Join(names, ",")
+	}
+	if ref, ok := field.Tag[tagRefColumn]; ok {
+		field.Tag[tagRefColumn] = qualify(ref)
+	}
+	return &field
+}
+
+// checkJSONStruct checks the fields of a query_expr json struct, which is parsed only when it
+// is built. A custom query_expr builds on a column, it has no json value to build on
+func checkJSONStruct(t reflect.Type, seen map[reflect.Type]bool) error {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || seen[t] {
+		return nil
+	}
+	seen[t] = true
+	for i := 0; i < t.NumField(); i++ {
+		structField := t.Field(i)
+		tag := schema.ParseTagSetting(structField.Tag.Get("gorm"), ";")
+		q, _ := negatedQueryExpr(resolveQueryExpr(tag[tagQuery]))
+		if expr, ok := lookupQueryExpr(q); ok && expr.custom {
+			return fmt.Errorf("field(%s) query_expr(%s) can not be used in json struct", structField.Name, q)
+		}
+		switch {
+		case structField.Anonymous, q == operatorJSON, q == operatorOr, q == operatorNot:
+			if err := checkJSONStruct(structField.Type, seen); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// isNullColumn reports whether the column has the suffix of the null query_expr
+func isNullColumn(column string) bool {
+	return strings.HasSuffix(column, nullColumnSuffix) && column != nullColumnSuffix
+}
+
 func isColumnEmpty(column string) bool {
 	return column == "" || column == "-"
 }
 
+// isColumnFree reports whether the query_expr does not need column tag
+func isColumnFree(queryExpr string) bool {
+	switch queryExpr {
+	case operatorOr, operatorNot, operatorExists, operatorNotExists, operatorHas, operatorHasCount, operatorRaw:
+		return true
+	}
+	return false
+}
+
+// checkRawSQL checks the template is a single condition, ? outside the quotes are
+// the placeholders
+func checkRawSQL(sql string) error {
+	if strings.TrimSpace(sql) == "" {
+		return fmt.Errorf("sql tag is empty")
+	}
+	var quote rune
+	depth, placeholders := 0, 0
+	for i, c := range sql {
+		if quote != 0 {
+			switch c {
+			case quote:
+				quote = 0
+			case '?':
+				// clause.Expr binds every ?, even the quoted one
+				return fmt.Errorf("? can not be quoted")
+			}
+			continue
+		}
+		switch c {
+		case '\'', '"', '`':
+			quote = c
+		case '(':
+			depth++
+		case ')':
+			if depth--; depth < 0 {
+				return fmt.Errorf("unbalanced parentheses")
+			}
+		case '?':
+			placeholders++
+		case ';':
+			return fmt.Errorf("; is not allowed")
+		case '-', '/':
+			if strings.HasPrefix(sql[i:], "--") || strings.HasPrefix(sql[i:], "/*") {
+				return fmt.Errorf("comment is not allowed")
+			}
+		}
+	}
+	switch {
+	case quote != 0:
+		return fmt.Errorf("unterminated quote")
+	case depth != 0:
+		return fmt.Errorf("unbalanced parentheses")
+	case placeholders == 0:
+		return fmt.Errorf("need ? placeholder")
+	}
+	return nil
+}
+
+func isIntegerKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
 func parseAnonymousStructField(structField reflect.StructField, sType *structType) error {
 	t := structField.Type
 	if t.Kind() == reflect.Ptr {
@@ -216,11 +697,11 @@ func parseAnonymousStructField(structField reflect.StructField, sType *structTyp
 	return nil
 }
 
-func parseNormalStructField(structField reflect.StructField, queryExprString string, updateExprString string, columnName string, tag map[string]string, fieldStructType reflect.Type, sType *structType) error {
-	if err := checkQueryExpr(structField, queryExprString); err != nil {
+func parseNormalStructField(structField reflect.StructField, queryExprString string, updateExprString string, columnName string, tag map[string]string, fieldStructType, nestedType reflect.Type, sType *structType) error {
+	if err := checkQueryExpr(structField, queryExprString, tag); err != nil {
 		return err
 	}
-	if err := checkUpdateExpr(structField, updateExprString); err != nil {
+	if err := checkUpdateExpr(structField, updateExprString, tag); err != nil {
 		return err
 	}
 	column := &fieldType{
@@ -231,8 +712,22 @@ func parseNormalStructField(structField reflect.StructField, queryExprString str
 		IsAnonymous: structField.Anonymous,
 		Kind:        structField.Type.Kind(),
 		OrType:      fieldStructType,
+		NestedType:  nestedType,
 		Tag:         tag,
 	}
+	if group, ok := tag[tagGroup]; ok {
+		join, prev := strings.ToLower(tag[tagGroupJoin]), sType.Groups[group]
+		if join != "" && prev != "" && join != prev {
+			return fmt.Errorf("field(%s) group(%s) group_join(%s) conflicts with group_join(%s)", structField.Name, group, join, prev)
+		}
+		if sType.Groups == nil {
+			sType.Groups = map[string]string{}
+		}
+		if join != "" || prev == "" {
+			sType.Groups[group] = join
+		}
+	}
+
 	// 已经有这个 name 的 field 这说明需要覆盖
 	if _, ok := sType.Fields[structField.Name]; ok {
 		reOrderNames(sType, structField.Name)
@@ -1,6 +1,7 @@
 package gormx
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"reflect"
@@ -130,6 +131,24 @@ func Test_ParseType(t *testing.T) {
 			return true
 		}},
 
+		{"ok - query_expr by type", reflect.TypeOf(struct {
+			A []int           `gorm:"column:a"`
+			B *Range[int]     `gorm:"column:b"`
+			C *bool           `gorm:"column:c_null"`
+			D json.RawMessage `gorm:"column:d"`
+		}{}), &structType{
+			Names: []string{"A", "B", "C", "D"},
+			Fields: map[string]*fieldType{
+				"A": {Name: "A", Column: "a", QueryExpr: "in", QueryByType: true, Kind: reflect.Slice, Tag: map[string]string{"COLUMN": "a"}},
+				"B": {Name: "B", Column: "b", QueryExpr: "between", QueryByType: true, Kind: reflect.Ptr, Tag: map[string]string{"COLUMN": "b"}},
+				"C": {Name: "C", Column: "c_null", QueryExpr: "null", QueryByType: true, Kind: reflect.Ptr, Tag: map[string]string{"COLUMN": "c_null"}},
+				"D": {Name: "D", Column: "d", Kind: reflect.Slice, Tag: map[string]string{"COLUMN": "d"}},
+			},
+		}, func(t assert.TestingT, err error, i ...interface{}) bool {
+			as.Nil(err)
+			return true
+		}},
+
 		{"ok - one field - pointer", reflect.TypeOf(struct {
 			A *int `gorm:"column:a"`
 		}{}), &structType{
@@ -282,6 +301,7 @@ func assertFieldTypeEqual(t assert.TestingT, a, b *fieldType, msg string) {
 	as.Equal(a.Name, b.Name, msg)
 	as.Equal(a.Column, b.Column, msg)
 	as.Equal(a.QueryExpr, b.QueryExpr, msg)
+	as.Equal(a.QueryByType, b.QueryByType, msg)
 	as.Equal(a.UpdateExpr, b.UpdateExpr, msg)
 	as.Equal(a.IsAnonymous, b.IsAnonymous, msg)
 	as.Equal(a.Kind, b.Kind, msg)
@@ -0,0 +1,103 @@
+package gormx
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+)
+
+var (
+	timeMu       sync.RWMutex
+	nowFunc      = time.Now
+	timeLocation *time.Location // nil is the location of the value
+
+	locationCache sync.Map // tz tag -> *time.Location
+
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+func setNowFunc(fn func() time.Time) {
+	if fn == nil {
+		fn = time.Now
+	}
+	timeMu.Lock()
+	defer timeMu.Unlock()
+	nowFunc = fn
+}
+
+func setLocation(loc *time.Location) {
+	timeMu.Lock()
+	defer timeMu.Unlock()
+	timeLocation = loc
+}
+
+func now() time.Time {
+	timeMu.RLock()
+	defer timeMu.RUnlock()
+	return nowFunc()
+}
+
+func loadLocation(name string) (*time.Location, error) {
+	if loc, ok := locationCache.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+	locationCache.Store(name, loc)
+	return loc, nil
+}
+
+// locationOf is the tz tag of the field, or the location set by SetLocation
+func locationOf(field *fieldType) (*time.Location, error) {
+	if name, ok := field.Tag[tagTZ]; ok {
+		return loadLocation(name)
+	}
+	timeMu.RLock()
+	defer timeMu.RUnlock()
+	return timeLocation, nil
+}
+
+// timeRange is the half-open range [start, end) of a time operator on value
+func timeRange(operator string, value interface{}, loc *time.Location) (start, end time.Time, err error) {
+	switch operator {
+	case operatorSameDay, operatorSameMonth:
+		t, ok := value.(time.Time)
+		if !ok {
+			return start, end, fmt.Errorf("%s need time.Time, but got %T", operator, value)
+		}
+		if loc != nil {
+			t = t.In(loc)
+		}
+		// time.Date normalizes day+1 and month+1, the length of a day follows DST
+		if operator == operatorSameDay {
+			start = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+			end = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		} else {
+			start = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+			end = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		}
+	case operatorWithin:
+		d, ok := value.(time.Duration)
+		if !ok {
+			return start, end, fmt.Errorf("%s need time.Duration, but got %T", operator, value)
+		}
+		// within the past d, a negative d is within the coming -d
+		start = now()
+		if loc != nil {
+			start = start.In(loc)
+		}
+		end = start
+		if d > 0 {
+			start = end.Add(-d)
+		} else {
+			end = start.Add(-d)
+		}
+	default:
+		return start, end, fmt.Errorf("time operator %s invalid", operator)
+	}
+	return start, end, nil
+}
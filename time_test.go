@@ -0,0 +1,52 @@
+package gormx
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_timeRange(t *testing.T) {
+	as := assert.New(t)
+	shanghai, err := loadLocation("Asia/Shanghai")
+	as.Nil(err)
+	newYork, err := loadLocation("America/New_York")
+	as.Nil(err)
+
+	tests := []struct {
+		name     string
+		operator string
+		value    interface{}
+		loc      *time.Location
+		start    time.Time
+		end      time.Time
+	}{
+		{"day", operatorSameDay, time.Date(2023, 5, 6, 7, 8, 9, 0, time.UTC), nil,
+			time.Date(2023, 5, 6, 0, 0, 0, 0, time.UTC), time.Date(2023, 5, 7, 0, 0, 0, 0, time.UTC)},
+		{"day in tz", operatorSameDay, time.Date(2023, 5, 6, 20, 0, 0, 0, time.UTC), shanghai,
+			time.Date(2023, 5, 7, 0, 0, 0, 0, shanghai), time.Date(2023, 5, 8, 0, 0, 0, 0, shanghai)},
+		{"dst day", operatorSameDay, time.Date(2023, 3, 12, 12, 0, 0, 0, newYork), nil,
+			time.Date(2023, 3, 12, 0, 0, 0, 0, newYork), time.Date(2023, 3, 12, 0, 0, 0, 0, newYork).Add(23 * time.Hour)},
+		{"month", operatorSameMonth, time.Date(2023, 12, 31, 23, 0, 0, 0, time.UTC), nil,
+			time.Date(2023, 12, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"within", operatorWithin, time.Hour, nil,
+			time.Date(2023, 5, 6, 6, 0, 0, 0, time.UTC), time.Date(2023, 5, 6, 7, 0, 0, 0, time.UTC)},
+		{"within coming", operatorWithin, -time.Hour, shanghai,
+			time.Date(2023, 5, 6, 15, 0, 0, 0, shanghai), time.Date(2023, 5, 6, 16, 0, 0, 0, shanghai)},
+	}
+
+	SetNowFunc(func() time.Time { return time.Date(2023, 5, 6, 7, 0, 0, 0, time.UTC) })
+	defer SetNowFunc(nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end, err := timeRange(tt.operator, tt.value, tt.loc)
+			as.Nil(err)
+			as.Equal(tt.start, start)
+			as.Equal(tt.end, end)
+		})
+	}
+
+	_, _, err = timeRange(operatorWithin, 1, nil)
+	as.Equal("within need time.Duration, but got int", err.Error())
+}
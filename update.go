@@ -1,9 +1,12 @@
 package gormx
 
 import (
+	"encoding"
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"strings"
+	"sync"
 
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
@@ -45,8 +48,26 @@ func buildUpdateMap(rv reflect.Value, structType *structType) (result map[string
 			data = data.Elem()
 		}
 		if column.UpdateExpr != "" {
-			updateExprBuilder := updaterMap[column.UpdateExpr] // 前置函数已经检查过，一定存在
-			if updaterResult := updateExprBuilder(column.Column, data.Interface()); updaterResult.SQL != "" {
+			updateExprBuilder, _ := lookupUpdateExpr(column.UpdateExpr) // 前置函数已经检查过，一定存在
+			updaterResult, err := updateExprBuilder(column, data.Interface())
+			if err != nil {
+				return nil, fmt.Errorf("field(%s) update_expr(%s) build failed: %w", column.Name, column.UpdateExpr, err)
+			}
+			// 同一列的多个 json path 修改, 依次作用在前一个修改的结果上
+			if update, ok := updaterResult.(jsonPathUpdate); ok {
+				if prev, ok := result[column.Column].(jsonPathUpdate); ok {
+					update.Doc = prev
+					updaterResult = update
+				}
+			}
+			// 同一列的多个位修改也一样
+			if update, ok := updaterResult.(bitUpdate); ok {
+				if prev, ok := result[column.Column].(bitUpdate); ok {
+					update.Column = prev
+					updaterResult = update
+				}
+			}
+			if updaterResult != nil {
 				result[column.Column] = updaterResult
 			}
 		} else {
@@ -61,32 +82,159 @@ const (
 	updateExprAdd       = "+"
 	updateExprSub       = "-"
 	updateExprMergeJSON = "merge_json"
+
+	updateExprJSONSet         = "json_set"          // path tag
+	updateExprJSONRemove      = "json_remove"       // path tag, bool value
+	updateExprJSONArrayAppend = "json_array_append" // path tag
+
+	updateExprBitSet   = "bit_set"   // bitUpdate, column | value
+	updateExprBitClear = "bit_clear" // bitUpdate, column & ~value
+	updateExprToggle   = "toggle"    // bitUpdate, column XOR value
 )
 
-type buildUpdateExpr func(field string, data interface{}) clause.Expr
+// UpdateExprBuilder builds the assignment value of an update_expr operator on column.
+// A nil expression skips the field, an error fails the whole update.
+type UpdateExprBuilder func(column string, data interface{}) (clause.Expression, error)
+
+// buildUpdateExpr is UpdateExprBuilder with the tag options of the field
+type buildUpdateExpr func(field *fieldType, data interface{}) (clause.Expression, error)
+
+var (
+	updaterMu sync.RWMutex
+
+	updaterAliases = map[string]string{
+		"add": updateExprAdd,
+		"sub": updateExprSub,
+	}
+)
 
 var updaterMap = map[string]buildUpdateExpr{
-	updateExprAdd: func(field string, data interface{}) clause.Expr {
-		return gorm.Expr(field+" + ?", data)
+	updateExprAdd: func(field *fieldType, data interface{}) (clause.Expression, error) {
+		return gorm.Expr("? + ?", clause.Column{Name: field.Column}, data), nil
 	},
-	updateExprSub: func(field string, data interface{}) clause.Expr {
-		return gorm.Expr(field+" - ?", data)
+	updateExprSub: func(field *fieldType, data interface{}) (clause.Expression, error) {
+		return gorm.Expr("? - ?", clause.Column{Name: field.Column}, data), nil
 	},
-	updateExprMergeJSON: func(field string, data interface{}) clause.Expr {
+	updateExprMergeJSON: func(field *fieldType, data interface{}) (clause.Expression, error) {
 		var bs []byte
+		var err error
 		if isMergeJSONStruct(data) {
-			dataMap, _ := mergeJSONStructToJSONMap(data)
-			bs, _ = json.Marshal(dataMap)
+			var dataMap map[string]interface{}
+			if dataMap, err = mergeJSONStructToJSONMap(data); err != nil {
+				return nil, err
+			}
+			bs, err = json.Marshal(dataMap)
 		} else {
-			bs, _ = json.Marshal(data)
+			bs, err = json.Marshal(data)
+		}
+		if err != nil {
+			return nil, err
 		}
 		s := string(bs)
 		if s == "" {
-			return clause.Expr{}
+			return nil, nil
 		}
 
-		return gorm.Expr("CASE WHEN (`"+field+"` IS NULL OR `"+field+"` = '') THEN CAST(? AS JSON) ELSE JSON_MERGE_PATCH(`"+field+"`, CAST(? AS JSON)) END", s, s)
+		return mergeJSON{Column: clause.Column{Name: field.Column}, Patch: s}, nil
+	},
+	updateExprJSONSet: func(field *fieldType, data interface{}) (clause.Expression, error) {
+		return buildJSONPathUpdate(updateExprJSONSet, field, data)
+	},
+	updateExprJSONRemove: func(field *fieldType, data interface{}) (clause.Expression, error) {
+		// the bool switches the remove on, false keeps the key
+		if remove, _ := data.(bool); !remove {
+			return nil, nil
+		}
+		return buildJSONPathUpdate(updateExprJSONRemove, field, nil)
 	},
+	updateExprJSONArrayAppend: func(field *fieldType, data interface{}) (clause.Expression, error) {
+		return buildJSONPathUpdate(updateExprJSONArrayAppend, field, data)
+	},
+	updateExprBitSet: func(field *fieldType, data interface{}) (clause.Expression, error) {
+		return bitUpdate{Operator: updateExprBitSet, Column: clause.Column{Name: field.Column}, Value: toInt64(data)}, nil
+	},
+	updateExprBitClear: func(field *fieldType, data interface{}) (clause.Expression, error) {
+		return bitUpdate{Operator: updateExprBitClear, Column: clause.Column{Name: field.Column}, Value: toInt64(data)}, nil
+	},
+	updateExprToggle: func(field *fieldType, data interface{}) (clause.Expression, error) {
+		return bitUpdate{Operator: updateExprToggle, Column: clause.Column{Name: field.Column}, Value: toInt64(data)}, nil
+	},
+}
+
+func buildJSONPathUpdate(operator string, field *fieldType, data interface{}) (clause.Expression, error) {
+	path, err := parseJSONPath(field.Tag[tagPath])
+	if err != nil {
+		return nil, err
+	}
+	update := jsonPathUpdate{
+		Operator: operator,
+		Doc:      clause.Column{Name: field.Column},
+		Path:     path,
+	}
+	if operator != updateExprJSONRemove {
+		bs, err := json.Marshal(data)
+		if err != nil {
+			return nil, err
+		}
+		update.Value = string(bs)
+	}
+	return update, nil
+}
+
+func lookupUpdateExpr(updateExprString string) (buildUpdateExpr, bool) {
+	updaterMu.RLock()
+	defer updaterMu.RUnlock()
+
+	if name, ok := updaterAliases[updateExprString]; ok {
+		updateExprString = name
+	}
+	builder, ok := updaterMap[updateExprString]
+	return builder, ok
+}
+
+// resolveUpdateExpr returns the operator name an alias points to
+func resolveUpdateExpr(updateExprString string) string {
+	updaterMu.RLock()
+	defer updaterMu.RUnlock()
+
+	if name, ok := updaterAliases[updateExprString]; ok {
+		return name
+	}
+	return updateExprString
+}
+
+func registerUpdateExpr(name string, builder UpdateExprBuilder, aliases []string) error {
+	if name == "" {
+		return fmt.Errorf("update_expr '%s' is reserved", name)
+	}
+	if builder == nil {
+		return fmt.Errorf("update_expr '%s' builder is nil", name)
+	}
+
+	updaterMu.Lock()
+	defer updaterMu.Unlock()
+
+	if other, ok := updaterAliases[name]; ok && other != name {
+		return fmt.Errorf("update_expr '%s' conflicts with an alias of update_expr '%s'", name, other)
+	}
+	for _, alias := range aliases {
+		if alias == "" || alias == name {
+			return fmt.Errorf("update_expr '%s' alias '%s' invalid", name, alias)
+		}
+		if _, ok := updaterMap[alias]; ok {
+			return fmt.Errorf("update_expr '%s' alias '%s' conflicts with a registered update_expr", name, alias)
+		}
+		if other, ok := updaterAliases[alias]; ok && other != name {
+			return fmt.Errorf("update_expr '%s' alias '%s' conflicts with an alias of update_expr '%s'", name, alias, other)
+		}
+	}
+	updaterMap[name] = func(field *fieldType, data interface{}) (clause.Expression, error) {
+		return builder(field.Column, data)
+	}
+	for _, alias := range aliases {
+		updaterAliases[alias] = name
+	}
+	return nil
 }
 
 func isMergeJSONStruct(v interface{}) bool {
@@ -94,45 +242,237 @@ func isMergeJSONStruct(v interface{}) bool {
 	if vt.Kind() == reflect.Ptr {
 		vt = vt.Elem()
 	}
-	return vt.Kind() == reflect.Struct
+	return vt.Kind() == reflect.Struct && !isJSONMarshaler(vt)
+}
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// isJSONMarshaler reports whether encoding/json encodes t by its own method
+func isJSONMarshaler(t reflect.Type) bool {
+	pt := reflect.PtrTo(t)
+	return t.Implements(jsonMarshalerType) || pt.Implements(jsonMarshalerType) ||
+		t.Implements(textMarshalerType) || pt.Implements(textMarshalerType)
 }
 
+// mergeJSONStructToJSONMap builds the merge patch of struct v the way encoding/json
+// encodes it, except that nil pointers are left out so the keys they map to are kept.
 func mergeJSONStructToJSONMap(v interface{}) (map[string]interface{}, error) {
-	vt := reflect.TypeOf(v)
 	vv := reflect.ValueOf(v)
-
-	if vt.Kind() == reflect.Ptr {
-		vt = vt.Elem()
+	if vv.Kind() == reflect.Ptr {
 		vv = vv.Elem()
 	}
-	if vt.Kind() != reflect.Struct {
+	if vv.Kind() != reflect.Struct {
 		return nil, fmt.Errorf("update(JSON_MERGE_PATCH) need struct type")
 	}
 
+	// addressable copy, so methods with pointer receiver are used by encoding/json
+	addressable := reflect.New(vv.Type()).Elem()
+	addressable.Set(vv)
+	return mergeJSONStruct(addressable)
+}
+
+func mergeJSONStruct(rv reflect.Value) (map[string]interface{}, error) {
 	m := map[string]interface{}{}
-	for i := 0; i < vt.NumField(); i++ {
-		vtField := vt.Field(i)
-		vvField := vv.Field(i)
+	for _, field := range jsonFields(rv.Type()) {
+		fv, ok := fieldByIndex(rv, field.index)
+		if !ok {
+			continue
+		}
+		if field.omitEmpty && isEmptyJSONValue(fv) {
+			continue
+		}
+		value, ok, err := mergeJSONValue(fv, field.quoted)
+		if err != nil {
+			return nil, err
+		} else if ok {
+			m[field.name] = value
+		}
+	}
+	return m, nil
+}
+
+// mergeJSONValue returns the patch value of rv, ok is false for nil pointers
+func mergeJSONValue(rv reflect.Value, quoted bool) (_ interface{}, ok bool, err error) {
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil, false, nil
+		}
+		rv = rv.Elem()
+	}
+
+	if isJSONMarshaler(rv.Type()) {
+		if rv.CanAddr() {
+			return rv.Addr().Interface(), true, nil
+		}
+		return rv.Interface(), true, nil
+	}
+
+	switch rv.Kind() {
+	case reflect.Struct:
+		m, err := mergeJSONStruct(rv)
+		return m, err == nil, err
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		if quoted {
+			bs, err := json.Marshal(rv.Interface())
+			if err != nil {
+				return nil, false, err
+			}
+			return string(bs), true, nil
+		}
+	}
+	return rv.Interface(), true, nil
+}
+
+type jsonField struct {
+	name      string
+	index     []int
+	depth     int
+	tagged    bool
+	omitEmpty bool
+	quoted    bool
+}
+
+// jsonFields returns the fields encoding/json encodes of struct t, fields of
+// embedded structs are promoted and the same name conflicts are resolved alike.
+func jsonFields(t reflect.Type) []jsonField {
+	var fields []jsonField
+	collectJSONFields(t, nil, map[reflect.Type]bool{t: true}, &fields)
+
+	byName := map[string][]jsonField{}
+	var names []string
+	for _, field := range fields {
+		if _, ok := byName[field.name]; !ok {
+			names = append(names, field.name)
+		}
+		byName[field.name] = append(byName[field.name], field)
+	}
+
+	result := make([]jsonField, 0, len(names))
+	for _, name := range names {
+		if field, ok := dominantJSONField(byName[name]); ok {
+			result = append(result, field)
+		}
+	}
+	return result
+}
 
-		if !vvField.IsValid() {
+func collectJSONFields(t reflect.Type, index []int, visited map[reflect.Type]bool, fields *[]jsonField) {
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		ft := sf.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if sf.Anonymous {
+			if !sf.IsExported() && ft.Kind() != reflect.Struct {
+				continue
+			}
+		} else if !sf.IsExported() {
 			continue
 		}
 
-		jsonField := vtField.Tag.Get("json")
-		if jsonField == "" || jsonField == "-" {
+		tag := sf.Tag.Get("json")
+		if tag == "-" {
 			continue
 		}
+		name, opts, _ := strings.Cut(tag, ",")
+		fieldIndex := append(index[:len(index):len(index)], i)
 
-		// ptr
-		if vtField.Type.Kind() == reflect.Ptr {
-			if vvField.IsNil() {
-				continue
+		// embedded struct without name, its fields are promoted
+		if name == "" && sf.Anonymous && ft.Kind() == reflect.Struct {
+			if !visited[ft] {
+				visited[ft] = true
+				collectJSONFields(ft, fieldIndex, visited, fields)
+				delete(visited, ft)
 			}
-			m[jsonField] = vvField.Elem().Interface()
-		} else {
-			m[jsonField] = vvField.Interface()
+			continue
+		}
+
+		field := jsonField{
+			name:   name,
+			index:  fieldIndex,
+			depth:  len(fieldIndex),
+			tagged: name != "",
 		}
+		if name == "" {
+			field.name = sf.Name
+		}
+		for _, opt := range strings.Split(opts, ",") {
+			switch opt {
+			case "omitempty":
+				field.omitEmpty = true
+			case "string":
+				field.quoted = true
+			}
+		}
+		*fields = append(*fields, field)
 	}
+}
 
-	return m, nil
+// dominantJSONField picks the shallowest field, a tagged one wins the tie and
+// the name is dropped if it is still ambiguous
+func dominantJSONField(fields []jsonField) (jsonField, bool) {
+	depth := fields[0].depth
+	for _, field := range fields {
+		if field.depth < depth {
+			depth = field.depth
+		}
+	}
+
+	var dominant []jsonField
+	var tagged []jsonField
+	for _, field := range fields {
+		if field.depth == depth {
+			dominant = append(dominant, field)
+			if field.tagged {
+				tagged = append(tagged, field)
+			}
+		}
+	}
+	if len(tagged) == 1 {
+		return tagged[0], true
+	}
+	if len(dominant) == 1 {
+		return dominant[0], true
+	}
+	return jsonField{}, false
+}
+
+// fieldByIndex is reflect.Value.FieldByIndex, but ok is false on nil embedded pointers
+func fieldByIndex(rv reflect.Value, index []int) (_ reflect.Value, ok bool) {
+	for i, x := range index {
+		if i > 0 && rv.Kind() == reflect.Ptr {
+			if rv.IsNil() {
+				return reflect.Value{}, false
+			}
+			rv = rv.Elem()
+		}
+		rv = rv.Field(x)
+	}
+	return rv, true
+}
+
+// isEmptyJSONValue is the omitempty rule of encoding/json
+func isEmptyJSONValue(rv reflect.Value) bool {
+	switch rv.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return rv.Len() == 0
+	case reflect.Bool:
+		return !rv.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return rv.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return rv.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return rv.IsNil()
+	}
+	return false
 }
@@ -1,7 +1,10 @@
 package gormx
 
 import (
+	"encoding/json"
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"gorm.io/gorm"
@@ -92,10 +95,10 @@ func TestBuildSQLUpdate(t *testing.T) {
 			Age: ptr[int](1),
 		}, func(m map[string]interface{}, sql string, err error) {
 			as.Nil(err)
-			as.Equal("UPDATE `user` SET `age`=age + 1 WHERE `id` = 1", sql)
+			as.Equal("UPDATE `user` SET `age`=`age` + 1 WHERE `id` = 1", sql)
 
 			as.Len(m, 1)
-			as.Equal(clause.Expr{SQL: "age + ?", Vars: []interface{}{1}}, m["age"])
+			as.Equal(clause.Expr{SQL: "? + ?", Vars: []interface{}{clause.Column{Name: "age"}, 1}}, m["age"])
 		})
 	})
 
@@ -106,10 +109,10 @@ func TestBuildSQLUpdate(t *testing.T) {
 			Age: ptr[int](1),
 		}, func(m map[string]interface{}, sql string, err error) {
 			as.Nil(err)
-			as.Equal("UPDATE `user` SET `age`=age - 1 WHERE `id` = 1", sql)
+			as.Equal("UPDATE `user` SET `age`=`age` - 1 WHERE `id` = 1", sql)
 
 			as.Len(m, 1)
-			as.Equal(clause.Expr{SQL: "age - ?", Vars: []interface{}{1}}, m["age"])
+			as.Equal(clause.Expr{SQL: "? - ?", Vars: []interface{}{clause.Column{Name: "age"}, 1}}, m["age"])
 		})
 	})
 
@@ -148,13 +151,10 @@ func TestBuildSQLUpdate(t *testing.T) {
 				Data: &m,
 			}, func(m map[string]interface{}, sql string, err error) {
 				as.Nil(err)
-				as.Equal("UPDATE `user` SET `data`=CASE WHEN (`data` IS NULL OR `data` = '') THEN CAST('{\"a\":\"a\",\"b\":2,\"c\":false}' AS JSON) ELSE JSON_MERGE_PATCH(`data`, CAST('{\"a\":\"a\",\"b\":2,\"c\":false}' AS JSON)) END WHERE `id` = 1", sql)
+				as.Equal("UPDATE `user` SET `data`=JSON_MERGE_PATCH(CASE WHEN (`data` IS NULL OR `data` = '') THEN JSON_OBJECT() ELSE `data` END, CAST('{\"a\":\"a\",\"b\":2,\"c\":false}' AS JSON)) WHERE `id` = 1", sql)
 
 				as.Len(m, 1)
-				as.Equal(clause.Expr{
-					SQL:  "CASE WHEN (`data` IS NULL OR `data` = '') THEN CAST(? AS JSON) ELSE JSON_MERGE_PATCH(`data`, CAST(? AS JSON)) END",
-					Vars: []interface{}{"{\"a\":\"a\",\"b\":2,\"c\":false}", "{\"a\":\"a\",\"b\":2,\"c\":false}"},
-				}, m["data"])
+				as.Equal(mergeJSON{Column: clause.Column{Name: "data"}, Patch: "{\"a\":\"a\",\"b\":2,\"c\":false}"}, m["data"])
 			})
 		})
 
@@ -175,13 +175,10 @@ func TestBuildSQLUpdate(t *testing.T) {
 				},
 			}, func(m map[string]interface{}, sql string, err error) {
 				as.Nil(err)
-				as.Equal("UPDATE `user` SET `data`=CASE WHEN (`data` IS NULL OR `data` = '') THEN CAST('{\"a\":\"a\",\"b\":2,\"c\":false}' AS JSON) ELSE JSON_MERGE_PATCH(`data`, CAST('{\"a\":\"a\",\"b\":2,\"c\":false}' AS JSON)) END WHERE `id` = 1", sql)
+				as.Equal("UPDATE `user` SET `data`=JSON_MERGE_PATCH(CASE WHEN (`data` IS NULL OR `data` = '') THEN JSON_OBJECT() ELSE `data` END, CAST('{\"a\":\"a\",\"b\":2,\"c\":false}' AS JSON)) WHERE `id` = 1", sql)
 
 				as.Len(m, 1)
-				as.Equal(clause.Expr{
-					SQL:  "CASE WHEN (`data` IS NULL OR `data` = '') THEN CAST(? AS JSON) ELSE JSON_MERGE_PATCH(`data`, CAST(? AS JSON)) END",
-					Vars: []interface{}{"{\"a\":\"a\",\"b\":2,\"c\":false}", "{\"a\":\"a\",\"b\":2,\"c\":false}"},
-				}, m["data"])
+				as.Equal(mergeJSON{Column: clause.Column{Name: "data"}, Patch: "{\"a\":\"a\",\"b\":2,\"c\":false}"}, m["data"])
 			})
 		})
 
@@ -197,13 +194,10 @@ func TestBuildSQLUpdate(t *testing.T) {
 				},
 			}, func(m map[string]interface{}, sql string, err error) {
 				as.Nil(err)
-				as.Equal("UPDATE `user` SET `data`=CASE WHEN (`data` IS NULL OR `data` = '') THEN CAST('{\"a\":null}' AS JSON) ELSE JSON_MERGE_PATCH(`data`, CAST('{\"a\":null}' AS JSON)) END WHERE `id` = 1", sql)
+				as.Equal("UPDATE `user` SET `data`=JSON_MERGE_PATCH(CASE WHEN (`data` IS NULL OR `data` = '') THEN JSON_OBJECT() ELSE `data` END, CAST('{\"a\":null}' AS JSON)) WHERE `id` = 1", sql)
 
 				as.Len(m, 1)
-				as.Equal(clause.Expr{
-					SQL:  "CASE WHEN (`data` IS NULL OR `data` = '') THEN CAST(? AS JSON) ELSE JSON_MERGE_PATCH(`data`, CAST(? AS JSON)) END",
-					Vars: []interface{}{"{\"a\":null}", "{\"a\":null}"},
-				}, m["data"])
+				as.Equal(mergeJSON{Column: clause.Column{Name: "data"}, Patch: "{\"a\":null}"}, m["data"])
 			})
 		})
 
@@ -224,13 +218,32 @@ func TestBuildSQLUpdate(t *testing.T) {
 				Data: &dataX,
 			}, func(m map[string]interface{}, sql string, err error) {
 				as.Nil(err)
-				as.Equal("UPDATE `user` SET `data`=CASE WHEN (`data` IS NULL OR `data` = '') THEN CAST('{\"a\":\"a\",\"b\":2,\"c\":false}' AS JSON) ELSE JSON_MERGE_PATCH(`data`, CAST('{\"a\":\"a\",\"b\":2,\"c\":false}' AS JSON)) END WHERE `id` = 1", sql)
+				as.Equal("UPDATE `user` SET `data`=JSON_MERGE_PATCH(CASE WHEN (`data` IS NULL OR `data` = '') THEN JSON_OBJECT() ELSE `data` END, CAST('{\"a\":\"a\",\"b\":2,\"c\":false}' AS JSON)) WHERE `id` = 1", sql)
 
 				as.Len(m, 1)
-				as.Equal(clause.Expr{
-					SQL:  "CASE WHEN (`data` IS NULL OR `data` = '') THEN CAST(? AS JSON) ELSE JSON_MERGE_PATCH(`data`, CAST(? AS JSON)) END",
-					Vars: []interface{}{"{\"a\":\"a\",\"b\":2,\"c\":false}", "{\"a\":\"a\",\"b\":2,\"c\":false}"},
-				}, m["data"])
+				as.Equal(mergeJSON{Column: clause.Column{Name: "data"}, Patch: "{\"a\":\"a\",\"b\":2,\"c\":false}"}, m["data"])
+			})
+		})
+
+		t.Run("struct-nested", func(t *testing.T) {
+			type notify struct {
+				Email *bool `json:"email,omitempty"`
+				SMS   *bool `json:"sms,omitempty"`
+			}
+			type settings struct {
+				Theme  *string `json:"theme,omitempty"`
+				Notify *notify `json:"notify,omitempty"`
+			}
+			testBuildSQLUpdate(struct {
+				Data *settings `gorm:"column:data; update_expr:merge_json"`
+			}{
+				Data: &settings{Notify: &notify{SMS: ptr(false)}},
+			}, func(m map[string]interface{}, sql string, err error) {
+				as.Nil(err)
+				as.Equal("UPDATE `user` SET `data`=JSON_MERGE_PATCH(CASE WHEN (`data` IS NULL OR `data` = '') THEN JSON_OBJECT() ELSE `data` END, CAST('{\"notify\":{\"sms\":false}}' AS JSON)) WHERE `id` = 1", sql)
+
+				as.Len(m, 1)
+				as.Equal(mergeJSON{Column: clause.Column{Name: "data"}, Patch: `{"notify":{"sms":false}}`}, m["data"])
 			})
 		})
 
@@ -252,6 +265,108 @@ func TestBuildSQLUpdate(t *testing.T) {
 			})
 		})
 	})
+
+	t.Run("json path", func(t *testing.T) {
+		type profile struct {
+			City string `json:"city"`
+		}
+		testBuildSQLUpdate(struct {
+			Profile  *profile `gorm:"column:data; update_expr:json_set; path:$.profile"`
+			City     *string  `gorm:"column:data; update_expr:json_set; path:$.profile.city"`
+			ClearAge *bool    `gorm:"column:data; update_expr:json_remove; path:$.age"`
+			Tag      *string  `gorm:"column:tags; update_expr:json_array_append; path:$.names"`
+		}{
+			City:     ptr("sh"),
+			ClearAge: ptr(true),
+			Tag:      ptr("new"),
+		}, func(m map[string]interface{}, sql string, err error) {
+			as.Nil(err)
+			as.Equal("UPDATE `user` SET `data`=JSON_REMOVE(JSON_SET(CASE WHEN (`data` IS NULL OR `data` = '') THEN JSON_OBJECT() ELSE `data` END, '$.profile.city', CAST('\"sh\"' AS JSON)), '$.age'),`tags`=JSON_ARRAY_APPEND(`tags`, '$.names', CAST('\"new\"' AS JSON)) WHERE `id` = 1", sql)
+
+			as.Len(m, 2)
+			as.Equal(jsonPathUpdate{
+				Operator: updateExprJSONRemove,
+				Doc: jsonPathUpdate{
+					Operator: updateExprJSONSet,
+					Doc:      clause.Column{Name: "data"},
+					Path:     JSONPath{"profile", "city"},
+					Value:    `"sh"`,
+				},
+				Path: JSONPath{"age"},
+			}, m["data"])
+		})
+
+		testBuildSQLUpdate(struct {
+			City     *string `gorm:"column:data; update_expr:json_set; path:$.profile.city"`
+			ClearAge *bool   `gorm:"column:data; update_expr:json_remove; path:$.age"`
+		}{
+			City:     ptr("sh"),
+			ClearAge: ptr(false),
+		}, func(m map[string]interface{}, sql string, err error) {
+			as.Nil(err)
+			as.Equal("UPDATE `user` SET `data`=JSON_SET(CASE WHEN (`data` IS NULL OR `data` = '') THEN JSON_OBJECT() ELSE `data` END, '$.profile.city', CAST('\"sh\"' AS JSON)) WHERE `id` = 1", sql)
+		})
+
+		testBuildSQLUpdate(struct {
+			City *string `gorm:"column:data; update_expr:json_set; path:profile.city"`
+		}{
+			City: ptr("sh"),
+		}, func(m map[string]interface{}, sql string, err error) {
+			as.NotNil(err)
+			as.Equal("field(City) update_expr(json_set) need valid path tag: json path(profile.city) must start with $", err.Error())
+		})
+
+		testBuildSQLUpdate(struct {
+			Age *int `gorm:"column:data; update_expr:json_remove; path:$.age"`
+		}{
+			Age: ptr(1),
+		}, func(m map[string]interface{}, sql string, err error) {
+			as.NotNil(err)
+			as.Equal("struct field(Age) with json_remove update_expr must be bool", err.Error())
+		})
+	})
+
+	t.Run("null column", func(t *testing.T) {
+		testBuildSQLUpdate(struct {
+			Flag *bool `gorm:"column:flag_null"`
+			Is   *bool `gorm:"column:is_null"`
+		}{
+			Flag: ptr(true),
+			Is:   ptr(true),
+		}, func(m map[string]interface{}, sql string, err error) {
+			as.Nil(err)
+			as.Equal("UPDATE `user` SET `flag_null`=true,`is_null`=true WHERE `id` = 1", sql)
+		})
+	})
+
+	t.Run("bit", func(t *testing.T) {
+		testBuildSQLUpdate(struct {
+			Set    *int   `gorm:"column:flags; update_expr:bit_set"`
+			Clear  *int   `gorm:"column:flags; update_expr:bit_clear"`
+			Toggle *uint8 `gorm:"column:mode; update_expr:toggle"`
+		}{
+			Set:    ptr(4),
+			Clear:  ptr(2),
+			Toggle: ptr(uint8(1)),
+		}, func(m map[string]interface{}, sql string, err error) {
+			as.Nil(err)
+			as.Equal("UPDATE `user` SET `flags`=(`flags` | 4) & -3,`mode`=`mode` ^ 1 WHERE `id` = 1", sql)
+			as.Equal(bitUpdate{
+				Operator: updateExprBitClear,
+				Column:   bitUpdate{Operator: updateExprBitSet, Column: clause.Column{Name: "flags"}, Value: 4},
+				Value:    2,
+			}, m["flags"])
+		})
+
+		testBuildSQLUpdate(struct {
+			Set *string `gorm:"column:flags; update_expr:bit_set"`
+		}{
+			Set: ptr("4"),
+		}, func(m map[string]interface{}, sql string, err error) {
+			as.NotNil(err)
+			as.Equal("struct field(Set) with bit_set update_expr must be integer", err.Error())
+		})
+	})
 }
 
 func Test_StructHelper(t *testing.T) {
@@ -314,6 +429,85 @@ func Test_StructHelper(t *testing.T) {
 		as.Nil(err)
 		as.Equal(map[string]interface{}(map[string]interface{}{"name": "name1", "age": int32(0)}), m)
 	}
+
+	{
+		type Address struct {
+			City   *string `json:"city,omitempty"`
+			Street *string `json:"street"`
+			Zip    string  `json:"zip,omitempty"`
+		}
+		type Base struct {
+			ID      *int64 `json:"id"`
+			Version int    `json:",string"`
+		}
+		m, err := mergeJSONStructToJSONMap(struct {
+			Base
+			Name     *string `json:"name,omitempty"`
+			Nick     string  `json:"nick,omitempty"`
+			Untagged *string
+			Address  *Address   `json:"address"`
+			Home     Address    `json:"home"`
+			Birthday *time.Time `json:"birthday"`
+			Level    jsonLevel  `json:"level"`
+			secret   string
+		}{
+			Base:     Base{Version: 2},
+			Name:     ptr("name1"),
+			Untagged: ptr("x"),
+			Address:  &Address{City: ptr("sh")},
+			Birthday: ptr(time.Date(2000, 1, 2, 0, 0, 0, 0, time.UTC)),
+			Level:    2,
+			secret:   "secret",
+		})
+		as.Nil(err)
+		as.Equal(map[string]interface{}{
+			"Version":  "2",
+			"name":     "name1",
+			"Untagged": "x",
+			"address":  map[string]interface{}{"city": "sh"},
+			"home":     map[string]interface{}{},
+			"birthday": ptr(time.Date(2000, 1, 2, 0, 0, 0, 0, time.UTC)),
+			"level":    ptr(jsonLevel(2)),
+		}, m)
+
+		bs, err := json.Marshal(m)
+		as.Nil(err)
+		as.Equal(`{"Untagged":"x","Version":"2","address":{"city":"sh"},"birthday":"2000-01-02T00:00:00Z","home":{},"level":"level-2","name":"name1"}`, string(bs))
+	}
+
+	{
+		type A struct {
+			Name string `json:"name"`
+		}
+		type B struct {
+			Name string `json:"name"`
+		}
+		type C struct {
+			Name string
+		}
+		m, err := mergeJSONStructToJSONMap(struct {
+			*A
+			B
+		}{B: B{Name: "b"}})
+		as.Nil(err)
+		as.Equal(map[string]interface{}{}, m, "ambiguous fields are dropped")
+
+		type D struct {
+			Name string `json:"Name"`
+		}
+		m, err = mergeJSONStructToJSONMap(struct {
+			C
+			D
+		}{C: C{Name: "c"}, D: D{Name: "d"}})
+		as.Nil(err)
+		as.Equal(map[string]interface{}{"Name": "d"}, m, "tagged field wins")
+	}
+}
+
+type jsonLevel int
+
+func (l *jsonLevel) MarshalJSON() ([]byte, error) {
+	return []byte(fmt.Sprintf(`"level-%d"`, *l)), nil
 }
 
 func Test_buildSQLUpdate(t *testing.T) {
@@ -325,3 +519,50 @@ func Test_buildSQLUpdate(t *testing.T) {
 		as.Equal("gormx's data is invalid", err.Error())
 	})
 }
+
+func TestRegisterUpdateExpr(t *testing.T) {
+	as := assert.New(t)
+
+	as.Nil(RegisterUpdateExpr("greatest", func(column string, data interface{}) (clause.Expression, error) {
+		if _, ok := data.(int); !ok {
+			return nil, fmt.Errorf("greatest need int, but got %T", data)
+		}
+		return gorm.Expr("GREATEST(?, ?)", clause.Column{Name: column}, data), nil
+	}, "max"))
+
+	m, err := buildSQLUpdate(struct {
+		Age   *int `gorm:"column:age; update_expr:greatest"`
+		Score *int `gorm:"column:score; update_expr:max"`
+		Count *int `gorm:"column:count; update_expr:add"`
+	}{
+		Age:   ptr(18),
+		Score: ptr(60),
+		Count: ptr(1),
+	})
+	as.Nil(err)
+	as.Equal(gorm.Expr("GREATEST(?, ?)", clause.Column{Name: "age"}, 18), m["age"])
+	as.Equal(gorm.Expr("GREATEST(?, ?)", clause.Column{Name: "score"}, 60), m["score"])
+	as.Equal(gorm.Expr("? + ?", clause.Column{Name: "count"}, 1), m["count"])
+
+	_, err = buildSQLUpdate(struct {
+		Age *string `gorm:"column:age; update_expr:greatest"`
+	}{Age: ptr("18")})
+	as.NotNil(err)
+	as.Equal("field(Age) update_expr(greatest) build failed: greatest need int, but got string", err.Error())
+
+	as.Equal("update_expr '' is reserved", RegisterUpdateExpr("", nil).Error())
+	as.Equal("update_expr 'x' builder is nil", RegisterUpdateExpr("x", nil).Error())
+	as.Equal("update_expr 'x' alias '+' conflicts with a registered update_expr", RegisterUpdateExpr("x", func(column string, data interface{}) (clause.Expression, error) {
+		return nil, nil
+	}, "+").Error())
+	as.Equal("update_expr 'my_add' alias 'add' conflicts with an alias of update_expr '+'", RegisterUpdateExpr("my_add", func(column string, data interface{}) (clause.Expression, error) {
+		return nil, nil
+	}, "add").Error())
+	as.Equal("update_expr 'add' conflicts with an alias of update_expr '+'", RegisterUpdateExpr("add", func(column string, data interface{}) (clause.Expression, error) {
+		return nil, nil
+	}).Error())
+	as.Nil(RegisterUpdateExpr("greatest", func(column string, data interface{}) (clause.Expression, error) {
+		return gorm.Expr("GREATEST(?, ?)", clause.Column{Name: column}, data), nil
+	}, "max"))
+	as.Equal("+", resolveUpdateExpr("add"))
+}
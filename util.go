@@ -3,8 +3,13 @@ package gormx
 import (
 	"fmt"
 	"reflect"
+
+	"gorm.io/gorm"
 )
 
+// gormDBType is the type of subquery values, they are not dereferenced
+var gormDBType = reflect.TypeOf(&gorm.DB{})
+
 func getValueAndType(structData interface{}) (reflect.Value, reflect.Type, error) {
 	rv := reflect.ValueOf(structData)
 
@@ -70,9 +75,23 @@ func isEmptyValue(rv reflect.Value) bool {
 		return true
 	case reflect.Complex64, reflect.Complex128:
 		return rv.Complex() == 0
-	case reflect.Slice, reflect.Array, reflect.Map:
+	case reflect.Slice, reflect.Map:
 		return rv.IsNil() || rv.Len() == 0
+	case reflect.Array:
+		// array 不能是 nil
+		return rv.Len() == 0
 	default:
 		return false
 	}
 }
+
+// toInt64 converts an integer, uint64 keeps its bits
+func toInt64(v interface{}) int64 {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(rv.Uint())
+	default:
+		return rv.Int()
+	}
+}
@@ -88,6 +88,9 @@ func Test_isEmptyValue(t *testing.T) {
 		{"slice-[]", []int{}, true},
 		{"slice-[1]", []int{1}, false},
 
+		{"array-[0]", [0]int{}, true},
+		{"array-[2]", [2]int{1, 2}, false},
+
 		{"map-{}", map[string]int{}, true},
 		{"map-{1}", map[string]int{"1": 1}, false},
 
@@ -1,10 +1,19 @@
 package gormx
 
 import (
+	"database/sql/driver"
+	"encoding/json"
 	"fmt"
 	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
+	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
 )
 
 func buildSQLWhere(where interface{}) (expression clause.Expression, err error) {
@@ -27,10 +36,70 @@ func buildSQLWhere(where interface{}) (expression clause.Expression, err error)
 	return buildClauseExpression(rv, sqlType, true)
 }
 
+// buildSQLOrder builds the relevance order of the fulltext fields tagged order:relevance
+func buildSQLOrder(where interface{}) (expression clause.Expression, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = packPanicError(r)
+		}
+	}()
+
+	rv, rt, err := getValueAndType(where)
+	if err != nil {
+		return nil, err
+	}
+
+	sqlType, err := parseStructType(rt)
+	if err != nil {
+		return nil, err
+	}
+
+	expressions := []clause.Expression{}
+	for _, name := range sqlType.Names {
+		column := sqlType.Fields[name]
+		if column.QueryExpr != operatorFullText || column.Tag[tagOrder] != fullTextOrderRelevance {
+			continue
+		}
+		data := rv.FieldByName(column.Name)
+		if isEmptyValue(data) {
+			continue
+		}
+		if data.Kind() == reflect.Ptr {
+			data = data.Elem()
+		}
+		match, err := buildFullText(column, data.Interface())
+		if err != nil {
+			return nil, fmt.Errorf("field(%s) query_expr(%s) build failed: %w", column.Name, column.QueryExpr, err)
+		}
+		expressions = append(expressions, clause.Expr{SQL: "? DESC", Vars: []interface{}{match}, WithoutParentheses: true})
+	}
+	if len(expressions) == 0 {
+		return nil, nil
+	}
+	return clause.CommaExpression{Exprs: expressions}, nil
+}
+
 func buildClauseExpression(rv reflect.Value, sqlType *structType, joinAnd bool) (result clause.Expression, err error) {
 	expressions := []clause.Expression{}
+	// group 的表达式放在 group 第一个表达式的位置
+	groupIndex, groupExprs := map[string]int{}, map[string][]clause.Expression{}
+	add := func(column *fieldType, expr clause.Expression) {
+		group, ok := column.Tag[tagGroup]
+		if !ok {
+			expressions = append(expressions, expr)
+			return
+		}
+		if _, ok := groupIndex[group]; !ok {
+			groupIndex[group] = len(expressions)
+			expressions = append(expressions, nil)
+		}
+		groupExprs[group] = append(groupExprs[group], expr)
+	}
 	for _, name := range sqlType.Names {
 		column := sqlType.Fields[name] // 前置步骤检查过，一定存在
+		if sqlType.Table != "" {
+			column = column.withTable(sqlType.Table)
+		}
 
 		// 计算字段的值
 		data := rv.FieldByName(column.Name)
@@ -38,7 +107,7 @@ func buildClauseExpression(rv reflect.Value, sqlType *structType, joinAnd bool)
 		if isEmptyValue(data) {
 			continue
 		}
-		if data.Kind() == reflect.Ptr {
+		if data.Kind() == reflect.Ptr && data.Type() != gormDBType {
 			data = data.Elem()
 		}
 		inter := data.Interface()
@@ -53,7 +122,15 @@ func buildClauseExpression(rv reflect.Value, sqlType *structType, joinAnd bool)
 			if err != nil {
 				return nil, err
 			}
-			if data.Kind() == reflect.Slice {
+			orType = orType.withTable(sqlType.Table)
+			if column.QueryExpr == operatorNot {
+				not, err := buildClauseExpression(data, orType, true)
+				if err != nil {
+					return nil, err
+				} else if not != nil {
+					add(column, clause.Not(not))
+				}
+			} else if data.Kind() == reflect.Slice {
 				list := []clause.Expression{}
 				for i := 0; i < data.Len(); i++ {
 					or, err := buildClauseExpression(data.Index(i), orType, false)
@@ -64,23 +141,29 @@ func buildClauseExpression(rv reflect.Value, sqlType *structType, joinAnd bool)
 					}
 				}
 				if len(list) > 0 {
-					expressions = append(expressions, joinExpression(list, false))
+					add(column, joinExpression(list, false))
 				}
 			} else {
 				or, err := buildClauseExpression(data, orType, false)
 				if err != nil {
 					return nil, err
 				} else if or != nil {
-					expressions = append(expressions, or)
+					add(column, or)
 				}
 			}
 		} else {
-			and := queryExprBuilder(column.Column, inter)
+			and, err := queryExprBuilder(column, funcValueOf(column, inter))
+			if err != nil {
+				return nil, fmt.Errorf("field(%s) query_expr(%s) build failed: %w", column.Name, column.QueryExpr, err)
+			}
 			if and != nil {
-				expressions = append(expressions, and)
+				add(column, and)
 			}
 		}
 	}
+	for group, index := range groupIndex {
+		expressions[index] = joinExpression(groupExprs[group], sqlType.Groups[group] == groupJoinAnd)
+	}
 
 	return joinExpression(expressions, joinAnd), nil
 }
@@ -97,8 +180,9 @@ func joinExpression(exprs []clause.Expression, joinAnd bool) clause.Expression {
 
 const (
 	operatorOr   = "or"     // clause.OrConditions
+	operatorNot  = "not"    // clause.NotConditions of the nested struct
 	operatorIn   = "in"     // clause.IN
-	operatorNin  = "not in" // notIn // 无 clause.NIN
+	operatorNin  = "not in" // clause.NotConditions of in // 无 clause.NIN
 	operatorGt   = ">"      // clause.Gt
 	operatorGte  = ">="     // clause.Gte
 	operatorLt   = "<"      // clause.Lt
@@ -107,121 +191,888 @@ const (
 	operatorNeq  = "!="     // clause.Neq
 	operatorLike = "like"   // clause.Like
 	operatorNull = "null"   // clause.Null
+
+	operatorJSON         = "json"          // nested struct, conditions on the json document of the column
+	operatorJSONContains = "json_contains" // jsonContains
+
+	operatorBetween    = "between"     // between, value is Range
+	operatorStartsWith = "starts_with" // escapedLike, value%
+	operatorEndsWith   = "ends_with"   // escapedLike, %value
+	operatorContains   = "contains"    // escapedLike, %value%
+
+	operatorFullText  = "fulltext"   // fullText, MATCH(columns) AGAINST(value)
+	operatorRegexp    = "regexp"     // regexpMatch
+	operatorNotRegexp = "not regexp" // regexpMatch
+
+	operatorExists     = "exists"      // exists, value is *gorm.DB
+	operatorNotExists  = "not exists"  // exists, value is *gorm.DB
+	operatorInSubquery = "in_subquery" // inSubquery of the nested struct, tag table and select
+
+	operatorHas      = "has"       // exists of associationSubquery, conditions of the nested struct
+	operatorHasCount = "has_count" // COUNT(*) of associationSubquery, value is integer or Range
+
+	operatorArrayContains    = "array_contains"     // arrayExpression, column @> value
+	operatorArrayOverlaps    = "array_overlaps"     // arrayExpression, column && value
+	operatorArrayContainedBy = "array_contained_by" // arrayExpression, column <@ value
+	operatorAny              = "any"                // arrayExpression, value = ANY(column)
+
+	operatorFindInSet = "find_in_set" // findInSet
+	operatorBitsAll   = "bits_all"    // column & value = value
+	operatorBitsAny   = "bits_any"    // column & value <> 0
+	operatorBitsNone  = "bits_none"   // column & value = 0
+
+	operatorNullSafeEq = "<=>"         // isDistinct, a nil pointer or NULL driver.Valuer is NULL
+	operatorIsDistinct = "is_distinct" // isDistinct
+
+	operatorSameDay   = "same_day"   // column >= start AND column < end, value is time.Time
+	operatorSameMonth = "same_month" // column >= start AND column < end, value is time.Time
+	operatorWithin    = "within"     // column >= start AND column < end, value is time.Duration before now
+
+	operatorWithinRadius = "within_radius" // geoWithin of spatial column, or haversine on columns lat,lng
+	operatorWithinBox    = "within_box"    // geoWithin of spatial column, or between on columns lat,lng
+
+	operatorRaw = "raw" // clause.Expr of the sql tag, the value is bound to each ?
+
+	operatorSearch = "search" // OR of escapedLike %value% over columns, AND of them for each term in mode terms
+
+	negationPrefix = "!" // !like, !between etc. is clause.NotConditions of the operator
+
+	// mode of search query_expr, the whole value by default
+	searchModeTerms = "terms"
+
+	// quantifier of like query_expr on a slice
+	quantifierAny = "any"
+	quantifierAll = "all"
+
+	// group_join of the group tag, or by default
+	groupJoinOr  = "or"
+	groupJoinAnd = "and"
+
+	// functions of the func tag, wrapping the column before the operator
+	funcLower  = "lower"
+	funcUpper  = "upper"
+	funcTrim   = "trim"
+	funcLength = "length"
+	funcDate   = "date"
+
+	// compare the column with the ref_column tag, the value is a bool switching it on
+	operatorColEq  = "col_eq"
+	operatorColNeq = "col_ne"
+	operatorColGt  = "col_gt"
+	operatorColGte = "col_gte"
+	operatorColLt  = "col_lt"
+	operatorColLte = "col_lte"
 )
 
-type (
-	buildExpression func(field string, data interface{}) clause.Expression
+// tag options of fulltext
+const (
+	fullTextModeNatural    = "natural"
+	fullTextModeBoolean    = "boolean"
+	fullTextOrderRelevance = "relevance"
+)
+
+// QueryExprBuilder builds the condition of a query_expr operator on column.
+// A nil expression skips the field, an error fails the whole query.
+type QueryExprBuilder func(column string, data interface{}) (clause.Expression, error)
+
+// buildExpression is QueryExprBuilder with the tag options of the field
+type buildExpression func(field *fieldType, data interface{}) (clause.Expression, error)
+
+type queryExpr struct {
+	build  buildExpression
+	custom bool // registered by RegisterQueryExpr
+}
+
+var (
+	queryExprMu sync.RWMutex
+
+	// URL-friendly names of the builtin operators
+	queryExprAliases = map[string]string{
+		"eq":  operatorEq,
+		"ne":  operatorNeq,
+		"neq": operatorNeq,
+		"gt":  operatorGt,
+		"gte": operatorGte,
+		"lt":  operatorLt,
+		"lte": operatorLte,
+		"nin": operatorNin,
+
+		"is_not_distinct": operatorNullSafeEq,
+	}
 )
 
-var queryExprMap = map[string]struct {
-	build buildExpression
-}{
+var queryExprMap = map[string]queryExpr{
 	operatorLt: {
-		build: func(field string, data interface{}) clause.Expression {
-			return clause.Lt{
-				Column: clause.Column{Name: field},
-				Value:  data,
+		build: func(field *fieldType, data interface{}) (clause.Expression, error) {
+			if refColumnOff(field, data) {
+				return nil, nil
 			}
+			return clause.Lt{
+				Column: columnOf(field, data),
+				Value:  valueOf(field, data),
+			}, nil
 		},
 	},
 	operatorLte: {
-		build: func(field string, data interface{}) clause.Expression {
-			return clause.Lte{
-				Column: clause.Column{Name: field},
-				Value:  data,
+		build: func(field *fieldType, data interface{}) (clause.Expression, error) {
+			if refColumnOff(field, data) {
+				return nil, nil
 			}
+			return clause.Lte{
+				Column: columnOf(field, data),
+				Value:  valueOf(field, data),
+			}, nil
 		},
 	},
 	operatorEq: {
-		build: func(field string, data interface{}) clause.Expression {
-			return clause.Eq{
-				Column: clause.Column{Name: field},
-				Value:  data,
+		build: func(field *fieldType, data interface{}) (clause.Expression, error) {
+			if refColumnOff(field, data) {
+				return nil, nil
 			}
+			return clause.Eq{
+				Column: columnOf(field, data),
+				Value:  valueOf(field, data),
+			}, nil
 		},
 	},
 	"": {
-		build: func(field string, data interface{}) clause.Expression {
-			return clause.Eq{
-				Column: clause.Column{Name: field},
-				Value:  data,
+		build: func(field *fieldType, data interface{}) (clause.Expression, error) {
+			if refColumnOff(field, data) {
+				return nil, nil
 			}
+			return clause.Eq{
+				Column: columnOf(field, data),
+				Value:  valueOf(field, data),
+			}, nil
 		},
 	},
 	operatorNeq: {
-		build: func(field string, data interface{}) clause.Expression {
-			return clause.Neq{
-				Column: clause.Column{Name: field},
-				Value:  data,
+		build: func(field *fieldType, data interface{}) (clause.Expression, error) {
+			if refColumnOff(field, data) {
+				return nil, nil
 			}
+			return clause.Neq{
+				Column: columnOf(field, data),
+				Value:  valueOf(field, data),
+			}, nil
 		},
 	},
 	operatorGt: {
-		build: func(field string, data interface{}) clause.Expression {
-			return clause.Gt{
-				Column: clause.Column{Name: field},
-				Value:  data,
+		build: func(field *fieldType, data interface{}) (clause.Expression, error) {
+			if refColumnOff(field, data) {
+				return nil, nil
 			}
+			return clause.Gt{
+				Column: columnOf(field, data),
+				Value:  valueOf(field, data),
+			}, nil
 		},
 	},
 	operatorGte: {
-		build: func(field string, data interface{}) clause.Expression {
-			return clause.Gte{
-				Column: clause.Column{Name: field},
-				Value:  data,
+		build: func(field *fieldType, data interface{}) (clause.Expression, error) {
+			if refColumnOff(field, data) {
+				return nil, nil
 			}
+			return clause.Gte{
+				Column: columnOf(field, data),
+				Value:  valueOf(field, data),
+			}, nil
 		},
 	},
 	operatorNull: {
-		build: func(field string, data interface{}) clause.Expression {
+		build: func(field *fieldType, data interface{}) (clause.Expression, error) {
+			if isNullColumn(field.Column) {
+				// deleted_at_null 是 deleted_at 的 IS NULL，只用于查询，update 仍是 deleted_at_null
+				column := *field
+				column.Column = strings.TrimSuffix(column.Column, nullColumnSuffix)
+				field = &column
+			}
 			switch v := data.(type) {
 			case bool:
 				if v {
 					return clause.Eq{
-						Column: clause.Column{Name: field},
+						Column: columnOf(field, nil),
 						Value:  nil,
-					}
+					}, nil
 				} else {
 					return clause.Neq{
-						Column: clause.Column{Name: field},
+						Column: columnOf(field, nil),
 						Value:  nil,
-					}
+					}, nil
 				}
 			}
-			return nil
+			return nil, nil
 		},
 	},
-	operatorIn: {
-		build: func(field string, data interface{}) clause.Expression {
-			return clause.IN{
-				Column: clause.Column{Name: field},
-				Values: interfaceToSlice(data),
+	operatorIn:  {build: buildIn},
+	operatorNin: {build: negateQueryExpr(buildIn)},
+	operatorLike: {
+		build: buildLike(func(field *fieldType, s string) clause.Expression {
+			return clause.Like{
+				Column: columnOf(field, s),
+				Value:  s,
+			}
+		}),
+	},
+	operatorBetween: {
+		build: func(field *fieldType, data interface{}) (clause.Expression, error) {
+			r, ok := data.(rangeExpression)
+			if !ok {
+				return nil, fmt.Errorf("between need gormx.Range, but got %T", data)
 			}
+			return r.rangeExpression(columnOf(field, data)), nil
 		},
 	},
-	operatorNin: {
-		build: func(field string, data interface{}) clause.Expression {
-			return notIn{clause.IN{
-				Column: clause.Column{Name: field},
-				Values: interfaceToSlice(data),
-			}}
+	operatorStartsWith: {build: buildLike(escapedLikeOf(false, true))},
+	operatorEndsWith:   {build: buildLike(escapedLikeOf(true, false))},
+	operatorContains:   {build: buildLike(escapedLikeOf(true, true))},
+	operatorFullText: {
+		build: func(field *fieldType, data interface{}) (clause.Expression, error) {
+			return buildFullText(field, data)
 		},
 	},
-	operatorLike: {
-		build: func(field string, data interface{}) clause.Expression {
-			return clause.Like{
-				Column: clause.Column{Name: field},
-				Value:  data.(string),
+	operatorRegexp: {
+		build: func(field *fieldType, data interface{}) (clause.Expression, error) {
+			return buildRegexp(field, data, false)
+		},
+	},
+	operatorNotRegexp: {
+		build: func(field *fieldType, data interface{}) (clause.Expression, error) {
+			return buildRegexp(field, data, true)
+		},
+	},
+	operatorExists: {
+		build: func(field *fieldType, data interface{}) (clause.Expression, error) {
+			return exists{Subquery: data}, nil
+		},
+	},
+	operatorNotExists: {
+		build: func(field *fieldType, data interface{}) (clause.Expression, error) {
+			return exists{Subquery: data, Not: true}, nil
+		},
+	},
+	operatorHasCount: {
+		build: func(field *fieldType, data interface{}) (clause.Expression, error) {
+			association := associationOf(field)
+			count := clause.Expr{SQL: "(?)", Vars: []interface{}{associationSubquery{Association: association, Alias: associationAlias(association), Count: true}}}
+			if r, ok := data.(rangeExpression); ok {
+				return r.rangeExpression(count), nil
+			}
+			return clause.Eq{Column: count, Value: data}, nil
+		},
+	},
+	operatorArrayContains:    {build: buildArrayExpression(operatorArrayContains)},
+	operatorArrayOverlaps:    {build: buildArrayExpression(operatorArrayOverlaps)},
+	operatorArrayContainedBy: {build: buildArrayExpression(operatorArrayContainedBy)},
+	operatorAny:              {build: buildArrayExpression(operatorAny)},
+	operatorFindInSet: {
+		build: func(field *fieldType, data interface{}) (clause.Expression, error) {
+			value := fmt.Sprint(data)
+			if strings.Contains(value, ",") {
+				return nil, fmt.Errorf("find_in_set value(%s) can not contain ','", value)
+			}
+			return findInSet{Column: columnOf(field, data), Value: value}, nil
+		},
+	},
+	operatorBitsAll: {
+		build: func(field *fieldType, data interface{}) (clause.Expression, error) {
+			return clause.Expr{SQL: "(? & ?) = ?", Vars: []interface{}{columnOf(field, data), data, data}, WithoutParentheses: true}, nil
+		},
+	},
+	operatorBitsAny: {
+		build: func(field *fieldType, data interface{}) (clause.Expression, error) {
+			return clause.Expr{SQL: "(? & ?) <> 0", Vars: []interface{}{columnOf(field, data), data}, WithoutParentheses: true}, nil
+		},
+	},
+	operatorBitsNone: {
+		build: func(field *fieldType, data interface{}) (clause.Expression, error) {
+			return clause.Expr{SQL: "(? & ?) = 0", Vars: []interface{}{columnOf(field, data), data}, WithoutParentheses: true}, nil
+		},
+	},
+	operatorNullSafeEq: {
+		build: func(field *fieldType, data interface{}) (clause.Expression, error) {
+			value, err := nullableValue(data)
+			if err != nil {
+				return nil, err
+			}
+			return isDistinct{Column: columnOf(field, data), Value: value}, nil
+		},
+	},
+	operatorIsDistinct: {
+		build: func(field *fieldType, data interface{}) (clause.Expression, error) {
+			value, err := nullableValue(data)
+			if err != nil {
+				return nil, err
+			}
+			return isDistinct{Column: columnOf(field, data), Value: value, Distinct: true}, nil
+		},
+	},
+	operatorSameDay:      {build: buildTimeRange(operatorSameDay)},
+	operatorSameMonth:    {build: buildTimeRange(operatorSameMonth)},
+	operatorWithin:       {build: buildTimeRange(operatorWithin)},
+	operatorWithinRadius: {build: buildGeo},
+	operatorWithinBox:    {build: buildGeo},
+	operatorColEq:        {build: buildColumnCompare(operatorEq)},
+	operatorColNeq:       {build: buildColumnCompare(operatorNeq)},
+	operatorColGt:        {build: buildColumnCompare(operatorGt)},
+	operatorColGte:       {build: buildColumnCompare(operatorGte)},
+	operatorColLt:        {build: buildColumnCompare(operatorLt)},
+	operatorColLte:       {build: buildColumnCompare(operatorLte)},
+	operatorRaw:          {build: buildRaw},
+	operatorSearch:       {build: buildSearch},
+	operatorJSONContains: {
+		build: func(field *fieldType, data interface{}) (clause.Expression, error) {
+			bs, err := json.Marshal(data)
+			if err != nil {
+				return nil, err
+			}
+			contains := jsonContains{Column: clause.Column{Name: field.JSONColumn}, Path: field.JSONPath, Value: string(bs)}
+			if field.JSONColumn == "" {
+				contains.Column = clause.Column{Name: field.Column}
+				if path, ok := field.Tag[tagPath]; ok {
+					if contains.Path, err = parseJSONPath(path); err != nil {
+						return nil, err
+					}
+				}
 			}
+			return contains, nil
 		},
 	},
-	operatorOr: {},
+	operatorOr:  {},
+	operatorNot: {},
+}
+
+// columnOf returns the left side of the field's condition, the column itself or
+// the value of the field in its json document, data decides the type of the value
+func columnOf(field *fieldType, data interface{}) interface{} {
+	var column interface{} = clause.Column{Name: field.Column}
+	if field.JSONColumn != "" {
+		// clause.Expr is the only expression gorm accepts as a column
+		column = clause.Expr{SQL: "?", Vars: []interface{}{jsonExtract{
+			Column: clause.Column{Name: field.JSONColumn},
+			Path:   field.JSONPath,
+			Type:   jsonValueType(data),
+		}}}
+	}
+	if name, ok := field.Tag[tagFunc]; ok {
+		column = clause.Expr{SQL: "?", Vars: []interface{}{columnFunc{Func: strings.ToLower(name), Column: column}}}
+	}
+	return column
+}
+
+// funcValueOf transforms data to match the column wrapped in the func tag
+func funcValueOf(field *fieldType, data interface{}) interface{} {
+	name, ok := field.Tag[tagFunc]
+	if !ok {
+		return data
+	}
+	var transform func(s string) string
+	switch strings.ToLower(name) {
+	case funcLower:
+		transform = strings.ToLower
+	case funcUpper:
+		transform = strings.ToUpper
+	case funcTrim:
+		transform = strings.TrimSpace
+	case funcDate:
+		if t, ok := data.(time.Time); ok {
+			return t.Format("2006-01-02")
+		}
+		return data
+	default:
+		return data
+	}
+	switch v := data.(type) {
+	case string:
+		return transform(v)
+	case []string:
+		list := make([]string, len(v))
+		for i := range v {
+			list[i] = transform(v[i])
+		}
+		return list
+	}
+	return data
+}
+
+func init() {
+	// registered here, these builders parse the nested struct which reads queryExprMap
+	queryExprMap[operatorJSON] = queryExpr{
+		build: func(field *fieldType, data interface{}) (clause.Expression, error) {
+			return buildJSONExpression(field, reflect.ValueOf(data))
+		},
+	}
+	queryExprMap[operatorHas] = queryExpr{
+		build: func(field *fieldType, data interface{}) (clause.Expression, error) {
+			return buildHas(field, reflect.ValueOf(data))
+		},
+	}
+	queryExprMap[operatorInSubquery] = queryExpr{
+		build: func(field *fieldType, data interface{}) (clause.Expression, error) {
+			return buildInSubquery(field, reflect.ValueOf(data))
+		},
+	}
+}
+
+func buildHas(field *fieldType, rv reflect.Value) (clause.Expression, error) {
+	sqlType, err := parseStructType(field.NestedType)
+	if err != nil {
+		return nil, err
+	}
+	// the columns of the nested struct are the columns of the association
+	association := associationOf(field)
+	alias := associationAlias(association)
+	where, err := buildClauseExpression(rv, sqlType.withTable(alias), true)
+	if err != nil {
+		return nil, err
+	}
+	return exists{Subquery: associationSubquery{Association: association, Alias: alias, Where: where}}, nil
+}
+
+// associationOf is the association tag, or the name of the field
+func associationOf(field *fieldType) string {
+	if name := field.Tag[tagAssociation]; name != "" {
+		return name
+	}
+	return field.Name
+}
+
+// associationAlias is the alias of the association table in its subquery, a self-referential
+// association would not tell its rows from the rows of the statement without it
+func associationAlias(association string) string {
+	return "has_" + schema.NamingStrategy{}.ColumnName("", association)
+}
+
+func buildInSubquery(field *fieldType, rv reflect.Value) (clause.Expression, error) {
+	sqlType, err := parseStructType(field.NestedType)
+	if err != nil {
+		return nil, err
+	}
+	where, err := buildClauseExpression(rv, sqlType, true)
+	if err != nil {
+		return nil, err
+	}
+	return inSubquery{
+		Column: columnOf(field, nil),
+		Subquery: selectSubquery{
+			Table:  field.Tag[tagTable],
+			Select: field.Tag[tagSelect],
+			Where:  where,
+		},
+	}, nil
+}
+
+// buildJSONExpression builds the conditions of the fields of a query_expr:json struct,
+// each field is a key of the json document
+func buildJSONExpression(field *fieldType, rv reflect.Value) (clause.Expression, error) {
+	sqlType, err := parseStructType(field.NestedType)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, base := field.JSONColumn, field.JSONPath
+	if doc == "" {
+		doc = field.Column
+		if path, ok := field.Tag[tagPath]; ok {
+			if base, err = parseJSONPath(path); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	expressions := []clause.Expression{}
+	for _, name := range sqlType.Names {
+		inner := *sqlType.Fields[name]
+		data := rv.FieldByName(inner.Name)
+		if isEmptyValue(data) {
+			continue
+		}
+		if data.Kind() == reflect.Ptr && data.Type() != gormDBType {
+			data = data.Elem()
+		}
+		if inner.OrType != nil {
+			return nil, fmt.Errorf("field(%s) query_expr(%s) can not be used in json struct", inner.Name, inner.QueryExpr)
+		}
+		if _, ok := inner.Tag[tagGroup]; ok {
+			return nil, fmt.Errorf("field(%s) group tag can not be used in json struct", inner.Name)
+		}
+
+		inner.JSONColumn = doc
+		inner.JSONPath = append(base[:len(base):len(base)], inner.Column)
+		queryExprBuilder, err := getQueryExpr(inner.QueryExpr)
+		if err != nil {
+			return nil, err
+		}
+		expr, err := queryExprBuilder(&inner, funcValueOf(&inner, data.Interface()))
+		if err != nil {
+			return nil, fmt.Errorf("field(%s) query_expr(%s) build failed: %w", inner.Name, inner.QueryExpr, err)
+		}
+		if expr != nil {
+			expressions = append(expressions, expr)
+		}
+	}
+	return joinExpression(expressions, true), nil
+}
+
+func buildFullText(field *fieldType, data interface{}) (clause.Expression, error) {
+	if field.JSONColumn != "" {
+		return nil, fmt.Errorf("query_expr '%s' can not be used in json struct", operatorFullText)
+	}
+	s, ok := data.(string)
+	if !ok {
+		return nil, fmt.Errorf("fulltext need string, but got %T", data)
+	}
+	match := fullText{Value: s, Mode: field.Tag[tagMode]}
+	if match.Mode == "" {
+		match.Mode = fullTextModeNatural
+	}
+	for _, name := range columnsOf(field.Column, field.Tag) {
+		match.Columns = append(match.Columns, clause.Column{Name: name})
+	}
+	return match, nil
+}
+
+// columnsOf is the columns tag option, or the column of the field
+func columnsOf(column string, tag map[string]string) []string {
+	columns, ok := tag[tagColumns]
+	if !ok {
+		return []string{column}
+	}
+	names := strings.Split(columns, ",")
+	for i := range names {
+		names[i] = strings.TrimSpace(names[i])
+	}
+	return names
+}
+
+func buildRegexp(field *fieldType, data interface{}, not bool) (clause.Expression, error) {
+	s, ok := data.(string)
+	if !ok {
+		return nil, fmt.Errorf("regexp need string, but got %T", data)
+	}
+	// 数据库的正则语法与 go 不完全相同，这里只拦截明显错误的 pattern
+	if _, err := regexp.Compile(s); err != nil {
+		return nil, fmt.Errorf("regexp(%s) invalid: %w", s, err)
+	}
+	_, ignoreCase := field.Tag[tagIgnoreCase]
+	return regexpMatch{
+		Column:     columnOf(field, data),
+		Pattern:    s,
+		Not:        not,
+		IgnoreCase: ignoreCase,
+	}, nil
+}
+
+func buildArrayExpression(operator string) buildExpression {
+	return func(field *fieldType, data interface{}) (clause.Expression, error) {
+		return arrayExpression{Operator: operator, Column: columnOf(field, nil), Value: data}, nil
+	}
+}
+
+// nullableValue is nil when data is a nil pointer, **T says NULL this way, or a
+// driver.Valuer of NULL such as sql.NullString, otherwise data without pointers
+func nullableValue(data interface{}) (interface{}, error) {
+	rv := reflect.ValueOf(data)
+	for {
+		if valuer, ok := rv.Interface().(driver.Valuer); ok {
+			if rv.Kind() == reflect.Ptr && rv.IsNil() {
+				return nil, nil
+			}
+			value, err := valuer.Value()
+			if err != nil || value == nil {
+				return nil, err
+			}
+			return valuer, nil
+		}
+		if rv.Kind() != reflect.Ptr {
+			return rv.Interface(), nil
+		}
+		if rv.IsNil() {
+			return nil, nil
+		}
+		rv = rv.Elem()
+	}
+}
+
+func buildTimeRange(operator string) buildExpression {
+	return func(field *fieldType, data interface{}) (clause.Expression, error) {
+		loc, err := locationOf(field)
+		if err != nil {
+			return nil, err
+		}
+		start, end, err := timeRange(operator, data, loc)
+		if err != nil {
+			return nil, err
+		}
+		return Range[time.Time]{From: &start, To: &end, ExclusiveTo: true}.rangeExpression(columnOf(field, nil)), nil
+	}
+}
+
+func buildGeo(field *fieldType, data interface{}) (clause.Expression, error) {
+	var err error
+	switch v := data.(type) {
+	case GeoRadius:
+		err = v.check()
+	case GeoBox:
+		err = v.check()
+	default:
+		return nil, fmt.Errorf("geo need gormx.GeoRadius or gormx.GeoBox, but got %T", data)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := field.Tag[tagColumns]; ok {
+		columns := columnsOf(field.Column, field.Tag)
+		lat, lng := clause.Column{Name: columns[0]}, clause.Column{Name: columns[1]}
+		if radius, ok := data.(GeoRadius); ok {
+			return latLngRadius(lat, lng, radius), nil
+		}
+		return latLngBox(lat, lng, data.(GeoBox)), nil
+	}
+	srid, _ := strconv.Atoi(field.Tag[tagSRID])
+	return geoWithin{Column: clause.Column{Name: field.Column}, Value: data, SRID: srid}, nil
+}
+
+// buildSearch matches each term of data in any of the columns
+func buildSearch(field *fieldType, data interface{}) (clause.Expression, error) {
+	if field.JSONColumn != "" {
+		return nil, fmt.Errorf("query_expr '%s' can not be used in json struct", operatorSearch)
+	}
+	s, ok := data.(string)
+	if !ok {
+		return nil, fmt.Errorf("search need string, but got %T", data)
+	}
+	terms := []string{strings.TrimSpace(s)}
+	if field.Tag[tagMode] == searchModeTerms {
+		terms = strings.Fields(s)
+	}
+	if len(terms) == 0 || terms[0] == "" {
+		return nil, nil
+	}
+	_, ignoreCase := field.Tag[tagIgnoreCase]
+	columns := columnsOf(field.Column, field.Tag)
+	ands := make([]clause.Expression, 0, len(terms))
+	for _, term := range terms {
+		ors := make([]clause.Expression, 0, len(columns))
+		for _, name := range columns {
+			ors = append(ors, escapedLike{
+				Column:     clause.Column{Name: name},
+				Value:      term,
+				AnyPrefix:  true,
+				AnySuffix:  true,
+				IgnoreCase: ignoreCase,
+			})
+		}
+		ands = append(ands, joinExpression(ors, false))
+	}
+	return joinExpression(ands, true), nil
+}
+
+func buildIn(field *fieldType, data interface{}) (clause.Expression, error) {
+	if db, ok := data.(*gorm.DB); ok {
+		return inSubquery{Column: columnOf(field, data), Subquery: db}, nil
+	}
+	return clause.IN{
+		Column: columnOf(field, data),
+		Values: interfaceToSlice(data),
+	}, nil
+}
+
+// negateQueryExpr negates the expression of build, by its NegationBuild when it has one
+func negateQueryExpr(build buildExpression) buildExpression {
+	return func(field *fieldType, data interface{}) (clause.Expression, error) {
+		expr, err := build(field, data)
+		if err != nil || expr == nil {
+			return expr, err
+		}
+		return clause.Not(expr), nil
+	}
+}
+
+// negatedQueryExpr returns the operator negated by the ! prefix
+func negatedQueryExpr(queryExprString string) (string, bool) {
+	if queryExprString == operatorNeq || !strings.HasPrefix(queryExprString, negationPrefix) {
+		return queryExprString, false
+	}
+	return queryExprString[len(negationPrefix):], true
+}
+
+// buildRaw binds data to each ? of the sql tag, a slice is expanded to (?,?)
+func buildRaw(field *fieldType, data interface{}) (clause.Expression, error) {
+	sql := field.Tag[tagSQL]
+	vars := make([]interface{}, strings.Count(sql, "?"))
+	for i := range vars {
+		vars[i] = data
+	}
+	return clause.Expr{SQL: sql, Vars: vars}, nil
+}
+
+// refColumnOff reports whether the bool of a ref_column field switches the comparison off
+func refColumnOff(field *fieldType, data interface{}) bool {
+	if _, ok := field.Tag[tagRefColumn]; !ok {
+		return false
+	}
+	on, _ := data.(bool)
+	return !on
+}
+
+// valueOf is the column of the ref_column tag, or data
+func valueOf(field *fieldType, data interface{}) interface{} {
+	if ref, ok := field.Tag[tagRefColumn]; ok {
+		return clause.Column{Name: ref}
+	}
+	return data
+}
+
+func buildColumnCompare(operator string) buildExpression {
+	return func(field *fieldType, data interface{}) (clause.Expression, error) {
+		if refColumnOff(field, data) {
+			return nil, nil
+		}
+		column, ref := columnOf(field, nil), clause.Column{Name: field.Tag[tagRefColumn]}
+		switch operator {
+		case operatorNeq:
+			return clause.Neq{Column: column, Value: ref}, nil
+		case operatorGt:
+			return clause.Gt{Column: column, Value: ref}, nil
+		case operatorGte:
+			return clause.Gte{Column: column, Value: ref}, nil
+		case operatorLt:
+			return clause.Lt{Column: column, Value: ref}, nil
+		case operatorLte:
+			return clause.Lte{Column: column, Value: ref}, nil
+		default:
+			return clause.Eq{Column: column, Value: ref}, nil
+		}
+	}
+}
+
+// buildLike builds like of the string, or of each string of a slice joined by the
+// quantifier tag, OR for any and AND for all
+func buildLike(like func(field *fieldType, s string) clause.Expression) buildExpression {
+	return func(field *fieldType, data interface{}) (clause.Expression, error) {
+		rv := reflect.ValueOf(data)
+		switch rv.Kind() {
+		case reflect.String:
+			return like(field, rv.String()), nil
+		case reflect.Slice, reflect.Array:
+		default:
+			return nil, fmt.Errorf("like need string or it's list, but got %T", data)
+		}
+		exprs := make([]clause.Expression, 0, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			item := rv.Index(i)
+			if item.Kind() == reflect.Ptr {
+				if item.IsNil() {
+					continue
+				}
+				item = item.Elem()
+			}
+			if item.Kind() != reflect.String {
+				return nil, fmt.Errorf("like need string or it's list, but got %T", data)
+			}
+			exprs = append(exprs, like(field, item.String()))
+		}
+		if len(exprs) == 0 {
+			return nil, nil
+		}
+		return joinExpression(exprs, field.Tag[tagQuantifier] == quantifierAll), nil
+	}
+}
+
+func escapedLikeOf(anyPrefix, anySuffix bool) func(field *fieldType, s string) clause.Expression {
+	return func(field *fieldType, s string) clause.Expression {
+		return escapedLike{
+			Column:    columnOf(field, s),
+			Value:     s,
+			AnyPrefix: anyPrefix,
+			AnySuffix: anySuffix,
+		}
+	}
 }
 
 func getQueryExpr(queryExprString string) (buildExpression, error) {
-	queryExpr, ok := queryExprMap[queryExprString]
+	queryExpr, ok := lookupQueryExpr(queryExprString)
 	if !ok {
 		return nil, fmt.Errorf("query_expr '%s' invalid", queryExprString)
 	}
 	return queryExpr.build, nil
 }
+
+func lookupQueryExpr(queryExprString string) (queryExpr, bool) {
+	queryExprMu.RLock()
+	defer queryExprMu.RUnlock()
+
+	if name, ok := queryExprAliases[queryExprString]; ok {
+		queryExprString = name
+	}
+	if base, ok := negatedQueryExpr(queryExprString); ok {
+		if base == "" {
+			// ! 不能否定默认的 =
+			return queryExpr{}, false
+		}
+		if name, ok := queryExprAliases[base]; ok {
+			base = name
+		}
+		expr, ok := queryExprMap[base]
+		if !ok || expr.build == nil {
+			// or 和 not 没有 build
+			return queryExpr{}, false
+		}
+		return queryExpr{build: negateQueryExpr(expr.build), custom: expr.custom}, true
+	}
+	queryExpr, ok := queryExprMap[queryExprString]
+	return queryExpr, ok
+}
+
+// resolveQueryExpr returns the operator name an alias points to
+func resolveQueryExpr(queryExprString string) string {
+	queryExprMu.RLock()
+	defer queryExprMu.RUnlock()
+
+	if name, ok := queryExprAliases[queryExprString]; ok {
+		return name
+	}
+	if base, ok := negatedQueryExpr(queryExprString); ok {
+		if name, ok := queryExprAliases[base]; ok {
+			return negationPrefix + name
+		}
+	}
+	return queryExprString
+}
+
+func registerQueryExpr(name string, builder QueryExprBuilder, aliases []string) error {
+	if name == "" || name == operatorOr || name == operatorNot || strings.HasPrefix(name, negationPrefix) {
+		return fmt.Errorf("query_expr '%s' is reserved", name)
+	}
+	if builder == nil {
+		return fmt.Errorf("query_expr '%s' builder is nil", name)
+	}
+
+	queryExprMu.Lock()
+	defer queryExprMu.Unlock()
+
+	if other, ok := queryExprAliases[name]; ok && other != name {
+		return fmt.Errorf("query_expr '%s' conflicts with an alias of query_expr '%s'", name, other)
+	}
+	for _, alias := range aliases {
+		if alias == "" || alias == name || strings.HasPrefix(alias, negationPrefix) {
+			return fmt.Errorf("query_expr '%s' alias '%s' invalid", name, alias)
+		}
+		if _, ok := queryExprMap[alias]; ok {
+			return fmt.Errorf("query_expr '%s' alias '%s' conflicts with a registered query_expr", name, alias)
+		}
+		if other, ok := queryExprAliases[alias]; ok && other != name {
+			return fmt.Errorf("query_expr '%s' alias '%s' conflicts with an alias of query_expr '%s'", name, alias, other)
+		}
+	}
+	// json struct 和 func tag 在 checkQueryExpr 里检查
+	queryExprMap[name] = queryExpr{build: func(field *fieldType, data interface{}) (clause.Expression, error) {
+		return builder(field.Column, data)
+	}, custom: true}
+	for _, alias := range aliases {
+		queryExprAliases[alias] = name
+	}
+	return nil
+}
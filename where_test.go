@@ -1,8 +1,13 @@
 package gormx
 
 import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
 	"reflect"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"gorm.io/gorm"
@@ -263,6 +268,1022 @@ func TestBuildSQLWhere(t *testing.T) {
 		})
 	})
 
+	t.Run("between", func(t *testing.T) {
+		t.Run("both bounds", func(t *testing.T) {
+			testBuildSQLWhere(struct {
+				Age Range[int] `gorm:"column:age; query_expr:between"`
+			}{
+				Age: Range[int]{From: ptr(18), To: ptr(30)},
+			}, func(expression clause.Expression, sql string, err error) {
+				as.Nil(err)
+				as.Equal("SELECT * FROM `user` WHERE `age` BETWEEN 18 AND 30", sql)
+				as.Equal(between{Column: clause.Column{Name: "age"}, From: 18, To: 30}, expression)
+			})
+		})
+
+		t.Run("exclusive", func(t *testing.T) {
+			testBuildSQLWhere(struct {
+				Age *Range[int] `gorm:"column:age; query_expr:between"`
+			}{
+				Age: &Range[int]{From: ptr(18), To: ptr(30), ExclusiveTo: true},
+			}, func(expression clause.Expression, sql string, err error) {
+				as.Nil(err)
+				as.Equal("SELECT * FROM `user` WHERE (`age` >= 18 AND `age` < 30)", sql)
+				exprs := assertExprList[clause.AndConditions](t, expression, 2)
+				assertExprEq[clause.Gte](t, exprs[0], "age", 18)
+				assertExprEq[clause.Lt](t, exprs[1], "age", 30)
+			})
+		})
+
+		t.Run("one side", func(t *testing.T) {
+			testBuildSQLWhere(struct {
+				Age   Range[int]     `gorm:"column:age; query_expr:between"`
+				Price Range[float64] `gorm:"column:price; query_expr:between"`
+			}{
+				Age:   Range[int]{From: ptr(18), ExclusiveFrom: true},
+				Price: Range[float64]{To: ptr(9.5)},
+			}, func(expression clause.Expression, sql string, err error) {
+				as.Nil(err)
+				as.Equal("SELECT * FROM `user` WHERE (`age` > 18 AND `price` <= 9.500000)", sql)
+				exprs := assertExprList[clause.AndConditions](t, expression, 2)
+				assertExprEq[clause.Gt](t, exprs[0], "age", 18)
+				assertExprEq[clause.Lte](t, exprs[1], "price", 9.5)
+			})
+		})
+
+		t.Run("no bound", func(t *testing.T) {
+			testBuildSQLWhere(struct {
+				Age Range[int] `gorm:"column:age; query_expr:between"`
+			}{}, func(expression clause.Expression, sql string, err error) {
+				as.Nil(err)
+				as.Equal("SELECT * FROM `user`", sql)
+				as.Nil(expression)
+			})
+		})
+
+		t.Run("invalid", func(t *testing.T) {
+			testBuildSQLWhere(struct {
+				Age []int `gorm:"column:age; query_expr:between"`
+			}{
+				Age: []int{18, 30},
+			}, func(expression clause.Expression, sql string, err error) {
+				as.NotNil(err)
+				as.Equal("struct field(Age) with between query_expr must be gormx.Range", err.Error())
+			})
+		})
+	})
+
+	t.Run("escaped like", func(t *testing.T) {
+		testBuildSQLWhere(struct {
+			Prefix   *string `gorm:"column:name; query_expr:starts_with"`
+			Suffix   *string `gorm:"column:email; query_expr:ends_with"`
+			Contains *string `gorm:"column:title; query_expr:contains"`
+		}{
+			Prefix:   ptr("bo"),
+			Suffix:   ptr("@x.com"),
+			Contains: ptr("50%_off"),
+		}, func(expression clause.Expression, sql string, err error) {
+			as.Nil(err)
+			as.Equal("SELECT * FROM `user` WHERE (`name` LIKE 'bo%' ESCAPE '\\\\' AND `email` LIKE '%@x.com' ESCAPE '\\\\' AND `title` LIKE '%50\\%\\_off%' ESCAPE '\\\\')", sql)
+			exprs := assertExprList[clause.AndConditions](t, expression, 3)
+			as.Equal(escapedLike{Column: clause.Column{Name: "name"}, Value: "bo", AnySuffix: true}, exprs[0])
+			as.Equal(escapedLike{Column: clause.Column{Name: "email"}, Value: "@x.com", AnyPrefix: true}, exprs[1])
+			as.Equal(escapedLike{Column: clause.Column{Name: "title"}, Value: "50%_off", AnyPrefix: true, AnySuffix: true}, exprs[2])
+		})
+
+		testBuildSQLWhere(struct {
+			Prefix *int `gorm:"column:name; query_expr:starts_with"`
+		}{
+			Prefix: ptr(1),
+		}, func(expression clause.Expression, sql string, err error) {
+			as.NotNil(err)
+			as.Equal("struct field(Prefix) with starts_with query_expr must be string or it's list", err.Error())
+		})
+	})
+
+	t.Run("json", func(t *testing.T) {
+		type Address struct {
+			City *string `gorm:"column:city"`
+		}
+		type Profile struct {
+			Name    *string  `gorm:"column:name; query_expr:starts_with"`
+			Age     *int     `gorm:"column:age; query_expr:>"`
+			Vip     *bool    `gorm:"column:vip"`
+			Level   []int    `gorm:"column:level; query_expr:in"`
+			Tags    []string `gorm:"column:tags; query_expr:json_contains"`
+			Address *Address `gorm:"column:address; query_expr:json"`
+		}
+
+		t.Run("nested", func(t *testing.T) {
+			testBuildSQLWhere(struct {
+				Profile *Profile `gorm:"column:data; query_expr:json"`
+			}{
+				Profile: &Profile{
+					Name:    ptr("bo"),
+					Age:     ptr(18),
+					Vip:     ptr(true),
+					Level:   []int{1, 2},
+					Tags:    []string{"go"},
+					Address: &Address{City: ptr("hz")},
+				},
+			}, func(expression clause.Expression, sql string, err error) {
+				as.Nil(err)
+				as.Equal("SELECT * FROM `user` WHERE (JSON_UNQUOTE(JSON_EXTRACT(`data`, '$.name')) LIKE 'bo%' ESCAPE '\\\\' AND "+
+					"CAST(JSON_EXTRACT(`data`, '$.age') AS DECIMAL(65,30)) > 18 AND "+
+					"(JSON_EXTRACT(`data`, '$.vip') = CAST('true' AS JSON)) = true AND "+
+					"CAST(JSON_EXTRACT(`data`, '$.level') AS DECIMAL(65,30)) IN (1,2) AND "+
+					"JSON_CONTAINS(`data`, CAST('[\"go\"]' AS JSON), '$.tags') AND "+
+					"JSON_UNQUOTE(JSON_EXTRACT(`data`, '$.address.city')) = 'hz')", sql)
+				exprs := assertExprList[clause.AndConditions](t, expression, 6)
+				as.Equal(jsonContains{Column: clause.Column{Name: "data"}, Path: JSONPath{"tags"}, Value: `["go"]`}, exprs[4])
+			})
+		})
+
+		t.Run("path", func(t *testing.T) {
+			testBuildSQLWhere(struct {
+				Profile Profile  `gorm:"column:data; query_expr:json; path:$.profile"`
+				Tags    []string `gorm:"column:tags; query_expr:json_contains"`
+			}{
+				Profile: Profile{Age: ptr(18)},
+				Tags:    []string{"go", "sql"},
+			}, func(expression clause.Expression, sql string, err error) {
+				as.Nil(err)
+				as.Equal("SELECT * FROM `user` WHERE (CAST(JSON_EXTRACT(`data`, '$.profile.age') AS DECIMAL(65,30)) > 18 AND "+
+					"JSON_CONTAINS(`tags`, CAST('[\"go\",\"sql\"]' AS JSON)))", sql)
+			})
+		})
+
+		t.Run("range", func(t *testing.T) {
+			testBuildSQLWhere(struct {
+				Profile struct {
+					Age   Range[int] `gorm:"column:age; query_expr:between"`
+					Level []int      `gorm:"column:level"`
+				} `gorm:"column:data; query_expr:json"`
+			}{
+				Profile: struct {
+					Age   Range[int] `gorm:"column:age; query_expr:between"`
+					Level []int      `gorm:"column:level"`
+				}{Age: Range[int]{From: ptr(18), To: ptr(30)}, Level: []int{1, 2}},
+			}, func(expression clause.Expression, sql string, err error) {
+				as.Nil(err)
+				as.Equal("SELECT * FROM `user` WHERE (CAST(JSON_EXTRACT(`data`, '$.age') AS DECIMAL(65,30)) BETWEEN 18 AND 30 AND "+
+					"CAST(JSON_EXTRACT(`data`, '$.level') AS DECIMAL(65,30)) IN (1,2))", sql)
+			})
+		})
+
+		t.Run("empty", func(t *testing.T) {
+			testBuildSQLWhere(struct {
+				Profile *Profile `gorm:"column:data; query_expr:json"`
+			}{
+				Profile: &Profile{},
+			}, func(expression clause.Expression, sql string, err error) {
+				as.Nil(err)
+				as.Nil(expression)
+			})
+		})
+
+		t.Run("invalid", func(t *testing.T) {
+			testBuildSQLWhere(struct {
+				Profile string `gorm:"column:data; query_expr:json"`
+			}{
+				Profile: "x",
+			}, func(expression clause.Expression, sql string, err error) {
+				as.NotNil(err)
+				as.Equal("struct field(Profile) with json query_expr must be struct", err.Error())
+			})
+
+			testBuildSQLWhere(struct {
+				Profile Profile `gorm:"column:data; query_expr:json; path:profile"`
+			}{
+				Profile: Profile{Age: ptr(18)},
+			}, func(expression clause.Expression, sql string, err error) {
+				as.NotNil(err)
+				as.Contains(err.Error(), "json path(profile) must start with $")
+			})
+		})
+	})
+
+	t.Run("fulltext", func(t *testing.T) {
+		t.Run("natural", func(t *testing.T) {
+			testBuildSQLWhere(struct {
+				Keyword *string `gorm:"column:title; query_expr:fulltext"`
+			}{
+				Keyword: ptr("gorm"),
+			}, func(expression clause.Expression, sql string, err error) {
+				as.Nil(err)
+				as.Equal("SELECT * FROM `user` WHERE MATCH(`title`) AGAINST('gorm' IN NATURAL LANGUAGE MODE)", sql)
+				as.Equal(fullText{Columns: []clause.Column{{Name: "title"}}, Value: "gorm", Mode: "natural"}, expression)
+			})
+		})
+
+		t.Run("boolean", func(t *testing.T) {
+			testBuildSQLWhere(struct {
+				Keyword string `gorm:"column:keyword; query_expr:fulltext; columns:title, body; mode:boolean"`
+			}{
+				Keyword: "+gorm -orm",
+			}, func(expression clause.Expression, sql string, err error) {
+				as.Nil(err)
+				as.Equal("SELECT * FROM `user` WHERE MATCH(`title`,`body`) AGAINST('+gorm -orm' IN BOOLEAN MODE)", sql)
+			})
+		})
+
+		t.Run("invalid", func(t *testing.T) {
+			testBuildSQLWhere(struct {
+				Keyword *string `gorm:"column:title; query_expr:fulltext; mode:phrase"`
+			}{}, func(expression clause.Expression, sql string, err error) {
+				as.NotNil(err)
+				as.Equal("field(Keyword) query_expr(fulltext) mode(phrase) invalid, must be natural or boolean", err.Error())
+			})
+
+			testBuildSQLWhere(struct {
+				Keyword *string `gorm:"column:title; query_expr:fulltext; order:asc"`
+			}{}, func(expression clause.Expression, sql string, err error) {
+				as.NotNil(err)
+				as.Equal("field(Keyword) query_expr(fulltext) order(asc) invalid, must be relevance", err.Error())
+			})
+
+			testBuildSQLWhere(struct {
+				Keyword *string `gorm:"column:title; query_expr:fulltext; columns:title,"`
+			}{}, func(expression clause.Expression, sql string, err error) {
+				as.NotNil(err)
+				as.Equal("field(Keyword) query_expr(fulltext) columns(title,) has empty column", err.Error())
+			})
+
+			testBuildSQLWhere(struct {
+				Keyword *int `gorm:"column:title; query_expr:fulltext"`
+			}{}, func(expression clause.Expression, sql string, err error) {
+				as.NotNil(err)
+				as.Equal("struct field(Keyword) with fulltext query_expr must be string", err.Error())
+			})
+		})
+	})
+
+	t.Run("regexp", func(t *testing.T) {
+		testBuildSQLWhere(struct {
+			Sku  *string `gorm:"column:sku; query_expr:regexp"`
+			Name *string `gorm:"column:name; query_expr:not regexp; ignore_case"`
+		}{
+			Sku:  ptr("^A[0-9]+$"),
+			Name: ptr("test"),
+		}, func(expression clause.Expression, sql string, err error) {
+			as.Nil(err)
+			as.Equal("SELECT * FROM `user` WHERE (`sku` REGEXP '^A[0-9]+$' AND NOT REGEXP_LIKE(`name`, 'test', 'i'))", sql)
+			exprs := assertExprList[clause.AndConditions](t, expression, 2)
+			as.Equal(regexpMatch{Column: clause.Column{Name: "sku"}, Pattern: "^A[0-9]+$"}, exprs[0])
+			as.Equal(regexpMatch{Column: clause.Column{Name: "name"}, Pattern: "test", Not: true, IgnoreCase: true}, exprs[1])
+		})
+
+		testBuildSQLWhere(struct {
+			Sku *string `gorm:"column:sku; query_expr:regexp"`
+		}{
+			Sku: ptr("A[0-9"),
+		}, func(expression clause.Expression, sql string, err error) {
+			as.NotNil(err)
+			as.Equal("field(Sku) query_expr(regexp) build failed: regexp(A[0-9) invalid: error parsing regexp: missing closing ]: `[0-9`", err.Error())
+		})
+
+		testBuildSQLWhere(struct {
+			Sku []string `gorm:"column:sku; query_expr:not regexp"`
+		}{}, func(expression clause.Expression, sql string, err error) {
+			as.NotNil(err)
+			as.Equal("struct field(Sku) with not regexp query_expr must be string", err.Error())
+		})
+	})
+
+	t.Run("subquery", func(t *testing.T) {
+		t.Run("gorm.DB", func(t *testing.T) {
+			orders := db.Table("orders").Select("user_id").Where("amount > ?", 100)
+			testBuildSQLWhere(struct {
+				ID      *gorm.DB `gorm:"column:id; query_expr:in"`
+				NotID   *gorm.DB `gorm:"column:id; query_expr:not in"`
+				Exists  *gorm.DB `gorm:"query_expr:exists"`
+				NoExist *gorm.DB `gorm:"query_expr:not exists"`
+			}{
+				ID:      orders,
+				NotID:   db.Table("banned").Select("user_id"),
+				Exists:  db.Table("orders").Select("1").Where("orders.user_id = user.id"),
+				NoExist: db.Table("refunds").Select("1").Where("refunds.user_id = user.id"),
+			}, func(expression clause.Expression, sql string, err error) {
+				as.Nil(err)
+				as.Equal("SELECT * FROM `user` WHERE (`id` IN (SELECT user_id FROM `orders` WHERE amount > 100) AND "+
+					"`id` NOT IN (SELECT user_id FROM `banned`) AND "+
+					"EXISTS (SELECT 1 FROM `orders` WHERE orders.user_id = user.id) AND "+
+					"NOT EXISTS (SELECT 1 FROM `refunds` WHERE refunds.user_id = user.id))", sql)
+				exprs := assertExprList[clause.AndConditions](t, expression, 4)
+				as.Equal(inSubquery{Column: clause.Column{Name: "id"}, Subquery: orders}, exprs[0])
+			})
+		})
+
+		t.Run("in_subquery", func(t *testing.T) {
+			type Order struct {
+				Status *string `gorm:"column:status"`
+				Amount *int    `gorm:"column:amount; query_expr:>"`
+			}
+			testBuildSQLWhere(struct {
+				Orders *Order `gorm:"column:id; query_expr:in_subquery; table:orders; select:user_id"`
+			}{
+				Orders: &Order{Status: ptr("paid"), Amount: ptr(100)},
+			}, func(expression clause.Expression, sql string, err error) {
+				as.Nil(err)
+				as.Equal("SELECT * FROM `user` WHERE `id` IN (SELECT `user_id` FROM `orders` WHERE (`status` = 'paid' AND `amount` > 100))", sql)
+			})
+
+			testBuildSQLWhere(struct {
+				Orders Order `gorm:"column:id; query_expr:in_subquery; table:orders; select:user_id"`
+			}{}, func(expression clause.Expression, sql string, err error) {
+				as.Nil(err)
+				as.Equal("SELECT * FROM `user` WHERE `id` IN (SELECT `user_id` FROM `orders`)", sql)
+			})
+		})
+
+		t.Run("invalid", func(t *testing.T) {
+			testBuildSQLWhere(struct {
+				Exists *string `gorm:"query_expr:exists"`
+			}{}, func(expression clause.Expression, sql string, err error) {
+				as.NotNil(err)
+				as.Equal("struct field(Exists) with exists query_expr must be *gorm.DB", err.Error())
+			})
+
+			testBuildSQLWhere(struct {
+				Orders *struct{} `gorm:"column:id; query_expr:in_subquery; table:orders"`
+			}{}, func(expression clause.Expression, sql string, err error) {
+				as.NotNil(err)
+				as.Equal("field(Orders) query_expr(in_subquery) need table and select tag", err.Error())
+			})
+
+			testBuildSQLWhere(struct {
+				Orders []int `gorm:"column:id; query_expr:in_subquery; table:orders; select:user_id"`
+			}{}, func(expression clause.Expression, sql string, err error) {
+				as.NotNil(err)
+				as.Equal("struct field(Orders) with in_subquery query_expr must be struct", err.Error())
+			})
+		})
+	})
+
+	t.Run("array", func(t *testing.T) {
+		testBuildSQLWhere(struct {
+			Tags []string `gorm:"column:tags; query_expr:array_contains"`
+			Tag  *string  `gorm:"column:tags; query_expr:any"`
+		}{
+			Tags: []string{"go"},
+			Tag:  ptr("go"),
+		}, func(expression clause.Expression, sql string, err error) {
+			as.Nil(err)
+			exprs := assertExprList[clause.AndConditions](t, expression, 2)
+			as.Equal(arrayExpression{Operator: "array_contains", Column: clause.Column{Name: "tags"}, Value: []string{"go"}}, exprs[0])
+			as.Equal(arrayExpression{Operator: "any", Column: clause.Column{Name: "tags"}, Value: "go"}, exprs[1])
+		})
+
+		testBuildSQLWhere(struct {
+			Tags *string `gorm:"column:tags; query_expr:array_overlaps"`
+		}{}, func(expression clause.Expression, sql string, err error) {
+			as.NotNil(err)
+			as.Equal("struct field(Tags) with array_overlaps query_expr must be slice/array", err.Error())
+		})
+
+		testBuildSQLWhere(struct {
+			Tags []string `gorm:"column:tags; query_expr:any"`
+		}{}, func(expression clause.Expression, sql string, err error) {
+			as.NotNil(err)
+			as.Equal("struct field(Tags) with any query_expr can not be slice/array", err.Error())
+		})
+	})
+
+	t.Run("bits", func(t *testing.T) {
+		testBuildSQLWhere(struct {
+			All  *int    `gorm:"column:flags; query_expr:bits_all"`
+			Any  *uint   `gorm:"column:flags; query_expr:bits_any"`
+			None *int64  `gorm:"column:flags; query_expr:bits_none"`
+			Tag  *string `gorm:"column:tags; query_expr:find_in_set"`
+		}{
+			All:  ptr(3),
+			Any:  ptr(uint(4)),
+			None: ptr(int64(8)),
+			Tag:  ptr("go"),
+		}, func(expression clause.Expression, sql string, err error) {
+			as.Nil(err)
+			as.Equal("SELECT * FROM `user` WHERE ((`flags` & 3) = 3 AND (`flags` & 4) <> 0 AND (`flags` & 8) = 0 AND FIND_IN_SET('go', `tags`) > 0)", sql)
+		})
+
+		testBuildSQLWhere(struct {
+			Tag *string `gorm:"column:tags; query_expr:find_in_set"`
+		}{
+			Tag: ptr("a,b"),
+		}, func(expression clause.Expression, sql string, err error) {
+			as.NotNil(err)
+			as.Equal("field(Tag) query_expr(find_in_set) build failed: find_in_set value(a,b) can not contain ','", err.Error())
+		})
+
+		testBuildSQLWhere(struct {
+			All *string `gorm:"column:flags; query_expr:bits_all"`
+		}{}, func(expression clause.Expression, sql string, err error) {
+			as.NotNil(err)
+			as.Equal("struct field(All) with bits_all query_expr must be integer", err.Error())
+		})
+	})
+
+	t.Run("null safe", func(t *testing.T) {
+		testBuildSQLWhere(struct {
+			ParentID   **int          `gorm:"column:parent_id; query_expr:<=>"`
+			OwnerID    **int          `gorm:"column:owner_id; query_expr:is_not_distinct"`
+			Status     *string        `gorm:"column:status; query_expr:is_distinct"`
+			DeletedBy  *sql.NullInt64 `gorm:"column:deleted_by; query_expr:is_distinct"`
+			ApprovedBy sql.NullInt64  `gorm:"column:approved_by; query_expr:<=>"`
+			Skipped    **int          `gorm:"column:skipped; query_expr:<=>"`
+		}{
+			ParentID:   ptr((*int)(nil)),
+			OwnerID:    ptr(ptr(3)),
+			Status:     ptr("done"),
+			DeletedBy:  &sql.NullInt64{},
+			ApprovedBy: sql.NullInt64{Int64: 5, Valid: true},
+		}, func(expression clause.Expression, sql string, err error) {
+			as.Nil(err)
+			as.Equal("SELECT * FROM `user` WHERE (`parent_id` <=> NULL AND `owner_id` <=> 3 AND NOT (`status` <=> 'done') AND "+
+				"NOT (`deleted_by` <=> NULL) AND `approved_by` <=> 5)", sql)
+			exprs := assertExprList[clause.AndConditions](t, expression, 5)
+			as.Equal(isDistinct{Column: clause.Column{Name: "parent_id"}}, exprs[0])
+			as.Equal(isDistinct{Column: clause.Column{Name: "owner_id"}, Value: 3}, exprs[1])
+		})
+
+		testBuildSQLWhere(struct {
+			IDs *[]int `gorm:"column:id; query_expr:<=>"`
+		}{}, func(expression clause.Expression, sql string, err error) {
+			as.NotNil(err)
+			as.Equal("struct field(IDs) with <=> query_expr can not be slice/array", err.Error())
+		})
+	})
+
+	t.Run("time", func(t *testing.T) {
+		SetNowFunc(func() time.Time { return time.Date(2023, 5, 6, 7, 0, 0, 0, time.UTC) })
+		defer SetNowFunc(nil)
+
+		testBuildSQLWhere(struct {
+			Day    *time.Time     `gorm:"column:created_at; query_expr:same_day"`
+			Month  *time.Time     `gorm:"column:paid_at; query_expr:same_month; tz:Asia/Shanghai"`
+			Within *time.Duration `gorm:"column:updated_at; query_expr:within"`
+		}{
+			Day:    ptr(time.Date(2023, 5, 6, 12, 0, 0, 0, time.UTC)),
+			Month:  ptr(time.Date(2023, 5, 31, 20, 0, 0, 0, time.UTC)),
+			Within: ptr(24 * time.Hour),
+		}, func(expression clause.Expression, sql string, err error) {
+			as.Nil(err)
+			as.Equal("SELECT * FROM `user` WHERE ((`created_at` >= '2023-05-06 00:00:00' AND `created_at` < '2023-05-07 00:00:00') AND "+
+				"(`paid_at` >= '2023-06-01 00:00:00' AND `paid_at` < '2023-07-01 00:00:00') AND "+
+				"(`updated_at` >= '2023-05-05 07:00:00' AND `updated_at` < '2023-05-06 07:00:00'))", sql)
+			exprs := assertExprList[clause.AndConditions](t, expression, 3)
+			shanghai, _ := time.LoadLocation("Asia/Shanghai")
+			month := assertExprList[clause.AndConditions](t, exprs[1], 2)
+			assertExprEq[clause.Gte](t, month[0], "paid_at", time.Date(2023, 6, 1, 0, 0, 0, 0, shanghai))
+			assertExprEq[clause.Lt](t, month[1], "paid_at", time.Date(2023, 7, 1, 0, 0, 0, 0, shanghai))
+		})
+
+		SetLocation(time.FixedZone("UTC-8", -8*3600))
+		defer SetLocation(nil)
+		testBuildSQLWhere(struct {
+			Day *time.Time `gorm:"column:created_at; query_expr:same_day"`
+		}{
+			Day: ptr(time.Date(2023, 5, 6, 5, 0, 0, 0, time.UTC)),
+		}, func(expression clause.Expression, sql string, err error) {
+			as.Nil(err)
+			as.Equal("SELECT * FROM `user` WHERE (`created_at` >= '2023-05-05 00:00:00' AND `created_at` < '2023-05-06 00:00:00')", sql)
+		})
+
+		testBuildSQLWhere(struct {
+			Day *time.Time `gorm:"column:created_at; query_expr:same_day; tz:Mars/Base"`
+		}{}, func(expression clause.Expression, sql string, err error) {
+			as.NotNil(err)
+			as.Equal("field(Day) query_expr(same_day) tz(Mars/Base) invalid: unknown time zone Mars/Base", err.Error())
+		})
+
+		testBuildSQLWhere(struct {
+			Within *int `gorm:"column:created_at; query_expr:within"`
+		}{}, func(expression clause.Expression, sql string, err error) {
+			as.NotNil(err)
+			as.Equal("struct field(Within) with within query_expr must be time.Duration", err.Error())
+		})
+	})
+
+	t.Run("geo", func(t *testing.T) {
+		testBuildSQLWhere(struct {
+			Near *GeoRadius `gorm:"column:location; query_expr:within_radius"`
+			Box  *GeoBox    `gorm:"column:location; query_expr:within_box; srid:4326"`
+			Name *string    `gorm:"column:name"`
+		}{
+			Near: &GeoRadius{Lat: 31.2, Lng: 121.5, Meters: 500},
+			Box:  &GeoBox{MinLat: 31, MinLng: 121, MaxLat: 32, MaxLng: 122},
+			Name: ptr("shop"),
+		}, func(expression clause.Expression, sql string, err error) {
+			as.Nil(err)
+			as.Equal("SELECT * FROM `user` WHERE (ST_Distance_Sphere(`location`, POINT(121.500000, 31.200000)) <= 500.000000 AND "+
+				"MBRContains(ST_GeomFromText('POLYGON((121 31,122 31,122 32,121 32,121 31))', 4326, 'axis-order=long-lat'), `location`) AND `name` = 'shop')", sql)
+			exprs := assertExprList[clause.AndConditions](t, expression, 3)
+			as.Equal(geoWithin{Column: clause.Column{Name: "location"}, Value: GeoRadius{Lat: 31.2, Lng: 121.5, Meters: 500}}, exprs[0])
+		})
+
+		testBuildSQLWhere(struct {
+			Box *GeoBox `gorm:"query_expr:within_box; columns:lat,lng"`
+		}{
+			Box: &GeoBox{MinLat: 10, MinLng: 170, MaxLat: 20, MaxLng: -170},
+		}, func(expression clause.Expression, sql string, err error) {
+			as.Nil(err)
+			as.Equal("SELECT * FROM `user` WHERE (`lat` BETWEEN 10.000000 AND 20.000000 AND (`lng` >= 170.000000 OR `lng` <= -170.000000))", sql)
+		})
+
+		testBuildSQLWhere(struct {
+			Near GeoRadius `gorm:"query_expr:within_radius; columns:lat, lng"`
+		}{
+			Near: GeoRadius{Lat: 0, Lng: 0, Meters: 1000},
+		}, func(expression clause.Expression, sql string, err error) {
+			as.Nil(err)
+			exprs := assertExprList[clause.AndConditions](t, expression, 2)
+			box := assertExprList[clause.AndConditions](t, exprs[0], 2)
+			lat := box[0].(between)
+			as.InDelta(-0.008993, lat.From, 1e-6)
+			as.InDelta(0.008993, lat.To, 1e-6)
+			as.Contains(sql, "6371008.800000 * 2 * ASIN(SQRT(POWER(SIN(RADIANS(`lat` - 0.000000) / 2), 2) + COS(RADIANS(0.000000)) * COS(RADIANS(`lat`)) * POWER(SIN(RADIANS(`lng` - 0.000000) / 2), 2))) <= 1000.000000")
+		})
+
+		testBuildSQLWhere(struct {
+			Near GeoRadius `gorm:"query_expr:within_radius; columns:lat"`
+		}{}, func(expression clause.Expression, sql string, err error) {
+			as.NotNil(err)
+			as.Equal("field(Near) query_expr(within_radius) columns(lat) must be lat,lng", err.Error())
+		})
+
+		testBuildSQLWhere(struct {
+			Near *GeoRadius `gorm:"column:location; query_expr:within_radius"`
+		}{
+			Near: &GeoRadius{Lat: 91, Meters: 1},
+		}, func(expression clause.Expression, sql string, err error) {
+			as.NotNil(err)
+			as.Equal("field(Near) query_expr(within_radius) build failed: geo radius center(91, 0) invalid", err.Error())
+		})
+
+		testBuildSQLWhere(struct {
+			Near *GeoBox `gorm:"column:location; query_expr:within_radius"`
+		}{}, func(expression clause.Expression, sql string, err error) {
+			as.NotNil(err)
+			as.Equal("struct field(Near) with within_radius query_expr must be gormx.GeoRadius", err.Error())
+		})
+	})
+
+	t.Run("ref column", func(t *testing.T) {
+		testBuildSQLWhere(struct {
+			Over     bool  `gorm:"column:used; query_expr:col_gt; ref_column:reserved"`
+			Same     *bool `gorm:"column:updated_at; query_expr:=; ref_column:created_at"`
+			Changed  *bool `gorm:"column:price; query_expr:col_ne; ref_column:origin_price"`
+			Disabled bool  `gorm:"column:stock; query_expr:col_lt; ref_column:locked"`
+		}{
+			Over:    true,
+			Same:    ptr(true),
+			Changed: ptr(true),
+		}, func(expression clause.Expression, sql string, err error) {
+			as.Nil(err)
+			as.Equal("SELECT * FROM `user` WHERE (`used` > `reserved` AND `updated_at` = `created_at` AND `price` <> `origin_price`)", sql)
+			exprs := assertExprList[clause.AndConditions](t, expression, 3)
+			as.Equal(clause.Gt{Column: clause.Column{Name: "used"}, Value: clause.Column{Name: "reserved"}}, exprs[0])
+		})
+
+		// false switches the comparison off, pointer or not
+		testBuildSQLWhere(struct {
+			Over    *bool `gorm:"column:a; query_expr:col_gt; ref_column:b"`
+			Same    *bool `gorm:"column:updated_at; query_expr:=; ref_column:created_at"`
+			NotSame *bool `gorm:"column:updated_at; query_expr:!=; ref_column:created_at"`
+			Changed *bool `gorm:"column:price; query_expr:col_ne; ref_column:origin_price"`
+		}{
+			Over:    ptr(false),
+			Same:    ptr(false),
+			NotSame: ptr(false),
+			Changed: ptr(true),
+		}, func(expression clause.Expression, sql string, err error) {
+			as.Nil(err)
+			as.Equal("SELECT * FROM `user` WHERE `price` <> `origin_price`", sql)
+		})
+
+		testBuildSQLWhere(struct {
+			Over bool `gorm:"column:used; query_expr:col_gt"`
+		}{}, func(expression clause.Expression, sql string, err error) {
+			as.NotNil(err)
+			as.Equal("field(Over) query_expr(col_gt) need ref_column tag", err.Error())
+		})
+
+		testBuildSQLWhere(struct {
+			Over int `gorm:"column:used; query_expr:>; ref_column:reserved"`
+		}{}, func(expression clause.Expression, sql string, err error) {
+			as.NotNil(err)
+			as.Equal("struct field(Over) with ref_column tag must be bool", err.Error())
+		})
+
+		testBuildSQLWhere(struct {
+			Over bool `gorm:"column:used; query_expr:null; ref_column:reserved"`
+		}{}, func(expression clause.Expression, sql string, err error) {
+			as.NotNil(err)
+			as.Equal("field(Over) query_expr(null) can not have ref_column tag", err.Error())
+		})
+	})
+
+	t.Run("func", func(t *testing.T) {
+		testBuildSQLWhere(struct {
+			Email  *string   `gorm:"column:email; func:LOWER"`
+			Codes  *[]string `gorm:"column:code; query_expr:in; func:upper"`
+			Name   *string   `gorm:"column:name; query_expr:starts_with; func:trim"`
+			Length *int      `gorm:"column:name; query_expr:<=; func:length"`
+		}{
+			Email:  ptr("A@B.com"),
+			Codes:  ptr([]string{"a", "b"}),
+			Name:   ptr(" jin "),
+			Length: ptr(8),
+		}, func(expression clause.Expression, sql string, err error) {
+			as.Nil(err)
+			as.Equal("SELECT * FROM `user` WHERE (LOWER(`email`) = 'a@b.com' AND UPPER(`code`) IN ('A','B') AND "+
+				"TRIM(`name`) LIKE 'jin%' ESCAPE '\\\\' AND CHAR_LENGTH(`name`) <= 8)", sql)
+		})
+
+		testBuildSQLWhere(struct {
+			Profile *struct {
+				City *string `gorm:"column:city; func:lower"`
+			} `gorm:"column:profile; query_expr:json"`
+		}{
+			Profile: &struct {
+				City *string `gorm:"column:city; func:lower"`
+			}{City: ptr("NY")},
+		}, func(expression clause.Expression, sql string, err error) {
+			as.Nil(err)
+			as.Equal("SELECT * FROM `user` WHERE LOWER(JSON_UNQUOTE(JSON_EXTRACT(`profile`, '$.city'))) = 'ny'", sql)
+		})
+
+		testBuildSQLWhere(struct {
+			Email *string `gorm:"column:email; func:lower(email)) OR (1=1"`
+		}{}, func(expression clause.Expression, sql string, err error) {
+			as.NotNil(err)
+			as.Equal("field(Email) query_expr() func(lower(email)) OR (1=1) not supported", err.Error())
+		})
+
+		testBuildSQLWhere(struct {
+			Sub *gorm.DB `gorm:"query_expr:exists; func:lower"`
+		}{}, func(expression clause.Expression, sql string, err error) {
+			as.NotNil(err)
+			as.Equal("field(Sub) query_expr(exists) can not have func tag", err.Error())
+		})
+
+		// the value would be changed by func while the column is not
+		testBuildSQLWhere(struct {
+			Tags []string `gorm:"column:tags; query_expr:json_contains; func:lower"`
+		}{}, func(expression clause.Expression, sql string, err error) {
+			as.NotNil(err)
+			as.Equal("field(Tags) query_expr(json_contains) can not have func tag", err.Error())
+		})
+
+		testBuildSQLWhere(struct {
+			Tags []string `gorm:"column:tags; query_expr:array_overlaps; func:upper"`
+		}{}, func(expression clause.Expression, sql string, err error) {
+			as.NotNil(err)
+			as.Equal("field(Tags) query_expr(array_overlaps) can not have func tag", err.Error())
+		})
+
+		testBuildSQLWhere(struct {
+			Count *int `gorm:"query_expr:has_count; association:Orders; func:length"`
+		}{}, func(expression clause.Expression, sql string, err error) {
+			as.NotNil(err)
+			as.Equal("field(Count) query_expr(has_count) can not have func tag", err.Error())
+		})
+	})
+
+	t.Run("raw", func(t *testing.T) {
+		testBuildSQLWhere(struct {
+			Name   *string `gorm:"query_expr:raw" sql:"COALESCE(nickname, name) LIKE ? OR email = ?"`
+			IDs    []int   `gorm:"query_expr:raw; sql:id IN ?"`
+			Status *string `gorm:"column:status"`
+		}{
+			Name:   ptr("bob"),
+			IDs:    []int{1, 2},
+			Status: ptr("on"),
+		}, func(expression clause.Expression, sql string, err error) {
+			as.Nil(err)
+			as.Equal("SELECT * FROM `user` WHERE ((COALESCE(nickname, name) LIKE 'bob' OR email = 'bob') AND id IN (1,2) AND `status` = 'on')", sql)
+			exprs := assertExprList[clause.AndConditions](t, expression, 3)
+			as.Equal(clause.Expr{SQL: "id IN ?", Vars: []interface{}{[]int{1, 2}}}, exprs[1])
+		})
+
+		tests := []struct {
+			sql string
+			err string
+		}{
+			{"", "sql tag is empty"},
+			{"name = 'a'", "need ? placeholder"},
+			{"name = ?; DROP TABLE user", "; is not allowed"},
+			{"name = ? -- x", "comment is not allowed"},
+			{"(name = ?", "unbalanced parentheses"},
+			{"name = ? OR name = 'x", "unterminated quote"},
+			{"name = ? OR name = 'x?'", "? can not be quoted"},
+		}
+		for _, tt := range tests {
+			as.EqualError(checkRawSQL(tt.sql), tt.err, tt.sql)
+		}
+
+		testBuildSQLWhere(struct {
+			Name *string `gorm:"query_expr:raw" sql:"name = ?; DROP TABLE user"`
+		}{}, func(expression clause.Expression, sql string, err error) {
+			as.NotNil(err)
+			as.Equal("field(Name) query_expr(raw) sql(name = ?; DROP TABLE user) invalid: ; is not allowed", err.Error())
+		})
+
+		testBuildSQLWhere(struct {
+			Name *string `gorm:"column:name; sql:name = ?"`
+		}{}, func(expression clause.Expression, sql string, err error) {
+			as.NotNil(err)
+			as.Equal("field(Name) query_expr() can not have sql tag", err.Error())
+		})
+
+		testBuildSQLWhere(struct {
+			Name *string `gorm:"column:name; query_expr:like" sql:"type:varchar(100)"`
+			Age  *int    `gorm:"column:age" sql:"type:int"`
+		}{
+			Name: ptr("bo%"),
+			Age:  ptr(3),
+		}, func(expression clause.Expression, sql string, err error) {
+			as.Nil(err)
+			as.Equal("SELECT * FROM `user` WHERE (`name` LIKE 'bo%' AND `age` = 3)", sql)
+		})
+	})
+
+	t.Run("negation", func(t *testing.T) {
+		type Banned struct {
+			Status *string `gorm:"column:status"`
+			Level  *int    `gorm:"column:level; query_expr:>"`
+		}
+		testBuildSQLWhere(struct {
+			Name     *string     `gorm:"column:name; query_expr:!starts_with"`
+			Email    *string     `gorm:"column:email; query_expr:!like"`
+			Age      *Range[int] `gorm:"column:age; query_expr:!between"`
+			Phone    *string     `gorm:"column:phone; query_expr:!regexp"`
+			IDs      *[]int      `gorm:"column:id; query_expr:!in"`
+			ParentID **int       `gorm:"column:parent_id; query_expr:!<=>"`
+			Banned   *Banned     `gorm:"query_expr:not"`
+			Single   *struct {
+				Role *string `gorm:"column:role"`
+			} `gorm:"query_expr:not"`
+		}{
+			Name:     ptr("a%"),
+			Email:    ptr("%@x.com"),
+			Age:      &Range[int]{From: ptr(18), To: ptr(30)},
+			Phone:    ptr("^1[0-9]+$"),
+			IDs:      ptr([]int{1, 2}),
+			ParentID: ptr((*int)(nil)),
+			Banned:   &Banned{Status: ptr("banned"), Level: ptr(3)},
+			Single: &struct {
+				Role *string `gorm:"column:role"`
+			}{Role: ptr("admin")},
+		}, func(expression clause.Expression, sql string, err error) {
+			as.Nil(err)
+			as.Equal("SELECT * FROM `user` WHERE (`name` NOT LIKE 'a\\%%' ESCAPE '\\\\' AND `email` NOT LIKE '%@x.com' AND "+
+				"`age` NOT BETWEEN 18 AND 30 AND NOT `phone` REGEXP '^1[0-9]+$' AND `id` NOT IN (1,2) AND "+
+				"NOT (`parent_id` <=> NULL) AND NOT (`status` = 'banned' AND `level` > 3) AND `role` <> 'admin')", sql)
+			exprs := assertExprList[clause.AndConditions](t, expression, 8)
+			assertExprNotIn(t, exprs[4], "id", []any{1, 2})
+		})
+
+		testBuildSQLWhere(struct {
+			Sub *struct {
+				Name *string `gorm:"column:name"`
+			} `gorm:"query_expr:!or"`
+		}{}, func(expression clause.Expression, sql string, err error) {
+			as.NotNil(err)
+			as.Equal("field(Sub) query_expr(!or) invalid", err.Error())
+		})
+
+		testBuildSQLWhere(struct {
+			Name *string `gorm:"column:name; query_expr:!!like"`
+		}{}, func(expression clause.Expression, sql string, err error) {
+			as.NotNil(err)
+			as.Equal("field(Name) query_expr(!!like) invalid", err.Error())
+		})
+
+		testBuildSQLWhere(struct {
+			A *int `gorm:"column:a; query_expr:!"`
+		}{}, func(expression clause.Expression, sql string, err error) {
+			as.NotNil(err)
+			as.Equal("field(A) query_expr(!) invalid", err.Error())
+		})
+
+		testBuildSQLWhere(struct {
+			Name *int `gorm:"column:name; query_expr:!contains"`
+		}{}, func(expression clause.Expression, sql string, err error) {
+			as.NotNil(err)
+			as.Equal("struct field(Name) with contains query_expr must be string or it's list", err.Error())
+		})
+
+		testBuildSQLWhere(struct {
+			Banned *string `gorm:"query_expr:not"`
+		}{}, func(expression clause.Expression, sql string, err error) {
+			as.NotNil(err)
+			as.Equal("struct field(Banned) with query_expr(not) must be struct", err.Error())
+		})
+	})
+
+	t.Run("group", func(t *testing.T) {
+		type Owned struct {
+			OwnerID *int `gorm:"column:owner_id; group:owner"`
+		}
+		testBuildSQLWhere(struct {
+			Name   *string `gorm:"column:name"`
+			Status *string `gorm:"column:status; group:visible; group_join:or"`
+			Owned
+			Public  *bool `gorm:"column:public; group:visible"`
+			AgeGte  *int  `gorm:"column:age; query_expr:>=; group:age; group_join:and"`
+			AgeLt   *int  `gorm:"column:age; query_expr:<; group:age"`
+			Skipped *int  `gorm:"column:skipped; group:skipped"`
+			Creator *int  `gorm:"column:creator_id; group:owner"`
+		}{
+			Name:   ptr("bob"),
+			Status: ptr("on"),
+			Owned:  Owned{OwnerID: ptr(1)},
+			Public: ptr(true),
+			AgeGte: ptr(18),
+			AgeLt:  ptr(30),
+		}, func(expression clause.Expression, sql string, err error) {
+			as.Nil(err)
+			as.Equal("SELECT * FROM `user` WHERE (`name` = 'bob' AND (`status` = 'on' OR `public` = true) AND `owner_id` = 1 AND "+
+				"(`age` >= 18 AND `age` < 30))", sql)
+			exprs := assertExprList[clause.AndConditions](t, expression, 4)
+			assertExprList[clause.OrConditions](t, exprs[1], 2)
+			assertExprEq[clause.Eq](t, exprs[2], "owner_id", 1)
+		})
+
+		testBuildSQLWhere(struct {
+			Status *string `gorm:"column:status; group:g; group_join:or"`
+			Owner  *int    `gorm:"column:owner; group:g; group_join:and"`
+		}{}, func(expression clause.Expression, sql string, err error) {
+			as.NotNil(err)
+			as.Equal("field(Owner) group(g) group_join(and) conflicts with group_join(or)", err.Error())
+		})
+
+		testBuildSQLWhere(struct {
+			Status *string `gorm:"column:status; group_join:or"`
+		}{}, func(expression clause.Expression, sql string, err error) {
+			as.NotNil(err)
+			as.Equal("field(Status) query_expr() need group tag", err.Error())
+		})
+
+		testBuildSQLWhere(struct {
+			Status *string `gorm:"column:status; group:g; group_join:xor"`
+		}{}, func(expression clause.Expression, sql string, err error) {
+			as.NotNil(err)
+			as.Equal("field(Status) query_expr() group_join(xor) must be or or and", err.Error())
+		})
+	})
+
+	t.Run("search", func(t *testing.T) {
+		testBuildSQLWhere(struct {
+			Keyword *string `gorm:"query_expr:search; columns:name, email,phone"`
+			Status  *string `gorm:"column:status"`
+		}{
+			Keyword: ptr(" bob_1 "),
+			Status:  ptr("on"),
+		}, func(expression clause.Expression, sql string, err error) {
+			as.Nil(err)
+			as.Equal("SELECT * FROM `user` WHERE ((`name` LIKE '%bob\\_1%' ESCAPE '\\\\' OR `email` LIKE '%bob\\_1%' ESCAPE '\\\\' OR "+
+				"`phone` LIKE '%bob\\_1%' ESCAPE '\\\\') AND `status` = 'on')", sql)
+		})
+
+		testBuildSQLWhere(struct {
+			Keyword *string `gorm:"query_expr:search; columns:name,email; mode:terms; ignore_case"`
+		}{
+			Keyword: ptr("Bob  Gmail"),
+		}, func(expression clause.Expression, sql string, err error) {
+			as.Nil(err)
+			as.Equal("SELECT * FROM `user` WHERE ((LOWER(`name`) LIKE '%bob%' ESCAPE '\\\\' OR LOWER(`email`) LIKE '%bob%' ESCAPE '\\\\') AND "+
+				"(LOWER(`name`) LIKE '%gmail%' ESCAPE '\\\\' OR LOWER(`email`) LIKE '%gmail%' ESCAPE '\\\\'))", sql)
+			exprs := assertExprList[clause.AndConditions](t, expression, 2)
+			assertExprList[clause.OrConditions](t, exprs[0], 2)
+		})
+
+		testBuildSQLWhere(struct {
+			Keyword *string `gorm:"column:name; query_expr:search; mode:terms"`
+		}{
+			Keyword: ptr("   "),
+		}, func(expression clause.Expression, sql string, err error) {
+			as.Nil(err)
+			as.Nil(expression)
+		})
+
+		testBuildSQLWhere(struct {
+			Keyword *string `gorm:"query_expr:search; columns:name,email; mode:boolean"`
+		}{}, func(expression clause.Expression, sql string, err error) {
+			as.NotNil(err)
+			as.Equal("field(Keyword) query_expr(search) mode(boolean) invalid, must be terms", err.Error())
+		})
+	})
+
+	t.Run("like quantifier", func(t *testing.T) {
+		testBuildSQLWhere(struct {
+			Prefixes *[]string `gorm:"column:code; query_expr:starts_with; quantifier:any"`
+			Names    []string  `gorm:"column:name; query_expr:like; quantifier:all"`
+			Tags     []*string `gorm:"column:tags; query_expr:!contains; quantifier:any"`
+			Empty    *[]string `gorm:"column:empty; query_expr:contains; quantifier:any"`
+			Single   *string   `gorm:"column:title; query_expr:ends_with"`
+		}{
+			Prefixes: ptr([]string{"A1", "B_"}),
+			Names:    []string{"%a%", "%b%"},
+			Tags:     []*string{ptr("x"), nil},
+			Empty:    ptr([]string{}),
+			Single:   ptr("z"),
+		}, func(expression clause.Expression, sql string, err error) {
+			as.Nil(err)
+			as.Equal("SELECT * FROM `user` WHERE ((`code` LIKE 'A1%' ESCAPE '\\\\' OR `code` LIKE 'B\\_%' ESCAPE '\\\\') AND "+
+				"(`name` LIKE '%a%' AND `name` LIKE '%b%') AND `tags` NOT LIKE '%x%' ESCAPE '\\\\' AND `title` LIKE '%z' ESCAPE '\\\\')", sql)
+			exprs := assertExprList[clause.AndConditions](t, expression, 4)
+			assertExprList[clause.OrConditions](t, exprs[0], 2)
+		})
+
+		testBuildSQLWhere(struct {
+			Prefixes [2]string  `gorm:"column:code; query_expr:starts_with; quantifier:any"`
+			Names    *[2]string `gorm:"column:name; query_expr:contains; quantifier:all"`
+		}{
+			Prefixes: [2]string{"a", "b"},
+			Names:    &[2]string{"x", "y"},
+		}, func(expression clause.Expression, sql string, err error) {
+			as.Nil(err)
+			as.Equal("SELECT * FROM `user` WHERE ((`code` LIKE 'a%' ESCAPE '\\\\' OR `code` LIKE 'b%' ESCAPE '\\\\') AND "+
+				"(`name` LIKE '%x%' ESCAPE '\\\\' AND `name` LIKE '%y%' ESCAPE '\\\\'))", sql)
+		})
+
+		testBuildSQLWhere(struct {
+			Prefixes []string `gorm:"column:code; query_expr:starts_with"`
+		}{}, func(expression clause.Expression, sql string, err error) {
+			as.NotNil(err)
+			as.Equal("struct field(Prefixes) with starts_with query_expr of slice/array need quantifier tag any or all", err.Error())
+		})
+
+		testBuildSQLWhere(struct {
+			Prefix *string `gorm:"column:code; query_expr:starts_with; quantifier:any"`
+		}{}, func(expression clause.Expression, sql string, err error) {
+			as.NotNil(err)
+			as.Equal("struct field(Prefix) with quantifier tag must be slice/array", err.Error())
+		})
+
+		testBuildSQLWhere(struct {
+			IDs []int `gorm:"column:id; query_expr:in; quantifier:any"`
+		}{}, func(expression clause.Expression, sql string, err error) {
+			as.NotNil(err)
+			as.Equal("field(IDs) query_expr(in) can not have quantifier tag", err.Error())
+		})
+	})
+
+	t.Run("query_expr by type", func(t *testing.T) {
+		testBuildSQLWhere(struct {
+			IDs      []int8          `gorm:"column:id"`
+			Age      *Range[int]     `gorm:"column:age"`
+			Deleted  *bool           `gorm:"column:deleted_at_null"`
+			Near     *GeoRadius      `gorm:"column:location"`
+			Raw      json.RawMessage `gorm:"column:raw"`
+			Name     *string         `gorm:"column:name"`
+			Reserved *bool           `gorm:"column:used; ref_column:reserved"`
+		}{
+			IDs:      []int8{1, 2},
+			Age:      &Range[int]{From: ptr(18), To: ptr(30)},
+			Deleted:  ptr(true),
+			Raw:      json.RawMessage(`{}`),
+			Name:     ptr("bob"),
+			Reserved: ptr(true),
+		}, func(expression clause.Expression, sql string, err error) {
+			as.Nil(err)
+			as.Equal("SELECT * FROM `user` WHERE (`id` IN (1,2) AND `age` BETWEEN 18 AND 30 AND `deleted_at` IS NULL AND "+
+				"`raw` = '{}' AND `name` = 'bob' AND `used` = `reserved`)", sql)
+		})
+
+		testBuildSQLWhere(struct {
+			Deleted   *bool `gorm:"column:deleted_at_null"`
+			NotPaid   *bool `gorm:"column:paid_at_null; query_expr:null"`
+			Confirmed *bool `gorm:"column:confirmed_at; query_expr:null"`
+		}{
+			Deleted:   ptr(true),
+			NotPaid:   ptr(true),
+			Confirmed: ptr(false),
+		}, func(expression clause.Expression, sql string, err error) {
+			as.Nil(err)
+			as.Equal("SELECT * FROM `user` WHERE (`deleted_at` IS NULL AND `paid_at` IS NULL AND `confirmed_at` IS NOT NULL)", sql)
+		})
+
+		testBuildSQLWhere(struct {
+			IDs []int `gorm:"column:id; query_expr:="`
+		}{}, func(expression clause.Expression, sql string, err error) {
+			as.NotNil(err)
+			as.Equal("struct field(IDs) with eq query_expr can not be slice/array", err.Error())
+		})
+
+		testBuildSQLWhere(struct {
+			IDs    [2]int   `gorm:"column:id"`
+			Status *[2]uint `gorm:"column:status"`
+		}{
+			IDs:    [2]int{1, 2},
+			Status: &[2]uint{3, 4},
+		}, func(expression clause.Expression, sql string, err error) {
+			as.Nil(err)
+			as.Equal("SELECT * FROM `user` WHERE (`id` IN (1,2) AND `status` IN (3,4))", sql)
+		})
+	})
+
 	t.Run("compare", func(t *testing.T) {
 		t.Run(">", func(t *testing.T) {
 			testBuildSQLWhere(struct {
@@ -645,11 +1666,14 @@ func assertExprEq[T any](t *testing.T, expression clause.Expression, column stri
 func assertExprNotIn(t *testing.T, expression clause.Expression, column string, value any) {
 	as := assert.New(t)
 
-	eq, ok := expression.(notIn)
+	not, ok := expression.(clause.NotConditions)
+	as.True(ok)
+	as.Len(not.Exprs, 1)
+	in, ok := not.Exprs[0].(clause.IN)
 	as.True(ok)
 
-	as.Equal(column, eq.in.Column.(clause.Column).Name)
-	as.Equal(value, eq.in.Values)
+	as.Equal(column, in.Column.(clause.Column).Name)
+	as.Equal(value, in.Values)
 }
 
 func assertExprList[T any](t *testing.T, expression clause.Expression, length int) []clause.Expression {
@@ -716,3 +1740,345 @@ func Test_buildClauseExpression(t *testing.T) {
 		as.Equal("query_expr 'invalid' invalid", err.Error())
 	})
 }
+
+func TestRegisterQueryExpr(t *testing.T) {
+	as := assert.New(t)
+	db := newDB()
+
+	toSQL := func(opt interface{}) string {
+		return db.ToSQL(func(tx *gorm.DB) *gorm.DB { return tx.Where(Query(opt)).Find(&[]User{}) })
+	}
+
+	t.Run("builtin alias", func(t *testing.T) {
+		as.Equal("SELECT * FROM `user` WHERE (`age` > 1 AND `age` <= 9 AND `id` NOT IN (1,2))", toSQL(struct {
+			AgeGt  *int  `gorm:"column:age; query_expr:gt"`
+			AgeLte *int  `gorm:"column:age; query_expr:lte"`
+			IDs    []int `gorm:"column:id; query_expr:nin"`
+		}{
+			AgeGt:  ptr(1),
+			AgeLte: ptr(9),
+			IDs:    []int{1, 2},
+		}))
+
+		_, err := buildSQLWhere(struct {
+			IDs int `gorm:"column:id; query_expr:nin"`
+		}{IDs: 1})
+		as.NotNil(err)
+		as.Equal("struct field(IDs) with not in query_expr must be slice/array", err.Error())
+	})
+
+	t.Run("custom", func(t *testing.T) {
+		as.Nil(RegisterQueryExpr("mod_zero", func(column string, data interface{}) (clause.Expression, error) {
+			n, ok := data.(int)
+			if !ok {
+				return nil, fmt.Errorf("mod_zero need int, but got %T", data)
+			}
+			return clause.Expr{SQL: "MOD(?, ?) = 0", Vars: []interface{}{clause.Column{Name: column}, n}}, nil
+		}, "mod0"))
+
+		as.Equal("SELECT * FROM `user` WHERE (MOD(`id`, 3) = 0 AND MOD(`age`, 2) = 0)", toSQL(struct {
+			ID  *int `gorm:"column:id; query_expr:mod_zero"`
+			Age *int `gorm:"column:age; query_expr:mod0"`
+		}{
+			ID:  ptr(3),
+			Age: ptr(2),
+		}))
+
+		_, err := buildSQLWhere(struct {
+			ID *string `gorm:"column:id; query_expr:mod_zero"`
+		}{ID: ptr("3")})
+		as.NotNil(err)
+		as.Equal("field(ID) query_expr(mod_zero) build failed: mod_zero need int, but got string", err.Error())
+
+		_, err = buildSQLWhere(struct {
+			ID *int `gorm:"column:id; query_expr:mod_one"`
+		}{ID: ptr(3)})
+		as.NotNil(err)
+		as.Equal("field(ID) query_expr(mod_one) invalid", err.Error())
+
+		// the builder gets the column name only, checked when the struct is parsed
+		_, err = parseStructType(reflect.TypeOf(struct {
+			ID *int `gorm:"column:id; query_expr:!mod0; func:length"`
+		}{}))
+		as.NotNil(err)
+		as.Equal("field(ID) query_expr(mod_zero) can not have func tag", err.Error())
+
+		type Profile struct {
+			Age *int `gorm:"column:age; query_expr:mod0"`
+		}
+		_, err = parseStructType(reflect.TypeOf(struct {
+			Profile *struct {
+				Inner Profile `gorm:"column:inner; query_expr:json"`
+			} `gorm:"column:data; query_expr:json"`
+		}{}))
+		as.NotNil(err)
+		as.Equal("field(Age) query_expr(mod_zero) can not be used in json struct", err.Error())
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		build := func(column string, data interface{}) (clause.Expression, error) { return nil, nil }
+
+		as.Equal("query_expr '' is reserved", RegisterQueryExpr("", build).Error())
+		as.Equal("query_expr 'or' is reserved", RegisterQueryExpr("or", build).Error())
+		as.Equal("query_expr 'x' builder is nil", RegisterQueryExpr("x", nil).Error())
+		as.Equal("query_expr 'x' alias 'in' conflicts with a registered query_expr", RegisterQueryExpr("x", build, "in").Error())
+		as.Equal("query_expr 'x' alias '' invalid", RegisterQueryExpr("x", build, "").Error())
+		as.Equal("query_expr 'my_gt' alias 'gt' conflicts with an alias of query_expr '>'", RegisterQueryExpr("my_gt", build, "gt").Error())
+		as.Equal("query_expr 'gt' conflicts with an alias of query_expr '>'", RegisterQueryExpr("gt", build).Error())
+		as.Equal("SELECT * FROM `user` WHERE `age` > 1", toSQL(struct {
+			Age *int `gorm:"column:age; query_expr:gt"`
+		}{Age: ptr(1)}))
+	})
+
+	t.Run("register again", func(t *testing.T) {
+		build := func(column string, data interface{}) (clause.Expression, error) {
+			return clause.Eq{Column: clause.Column{Name: column}, Value: data}, nil
+		}
+		as.Nil(RegisterQueryExpr("again", build, "again_1"))
+		as.Nil(RegisterQueryExpr("again", build, "again_1"))
+		as.Equal("query_expr 'again_other' alias 'again_1' conflicts with an alias of query_expr 'again'",
+			RegisterQueryExpr("again_other", build, "again_1").Error())
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				as.Nil(RegisterQueryExpr(fmt.Sprintf("concurrent_%d", i), func(column string, data interface{}) (clause.Expression, error) {
+					return clause.Eq{Column: clause.Column{Name: column}, Value: data}, nil
+				}))
+				_, err := getQueryExpr(operatorEq)
+				as.Nil(err)
+			}(i)
+		}
+		wg.Wait()
+	})
+}
+
+func TestOrder(t *testing.T) {
+	as := assert.New(t)
+	db := newDB()
+
+	type Search struct {
+		Title   *string `gorm:"column:title; query_expr:fulltext; order:relevance"`
+		Body    *string `gorm:"column:body; query_expr:fulltext; mode:boolean; order:relevance"`
+		Summary *string `gorm:"column:summary; query_expr:fulltext"`
+	}
+
+	t.Run("relevance", func(t *testing.T) {
+		search := Search{Title: ptr("gorm"), Body: ptr("+sql"), Summary: ptr("orm")}
+		sql := db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+			return tx.Where(Query(search)).Clauses(Order(search)).Find(&[]User{})
+		})
+		as.Equal("SELECT * FROM `user` WHERE (MATCH(`title`) AGAINST('gorm' IN NATURAL LANGUAGE MODE) AND "+
+			"MATCH(`body`) AGAINST('+sql' IN BOOLEAN MODE) AND MATCH(`summary`) AGAINST('orm' IN NATURAL LANGUAGE MODE)) "+
+			"ORDER BY MATCH(`title`) AGAINST('gorm' IN NATURAL LANGUAGE MODE) DESC, MATCH(`body`) AGAINST('+sql' IN BOOLEAN MODE) DESC", sql)
+	})
+
+	t.Run("after order", func(t *testing.T) {
+		search := Search{Title: ptr("gorm")}
+		sql := db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+			return tx.Order("id").Clauses(Order(search)).Find(&[]User{})
+		})
+		as.Equal("SELECT * FROM `user` ORDER BY id, MATCH(`title`) AGAINST('gorm' IN NATURAL LANGUAGE MODE) DESC", sql)
+	})
+
+	t.Run("before order", func(t *testing.T) {
+		search := Search{Title: ptr("gorm")}
+		sql := db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+			return tx.Clauses(Order(search)).Order("id").Order(clause.OrderByColumn{Column: clause.Column{Name: "name"}, Desc: true}).Find(&[]User{})
+		})
+		as.Equal("SELECT * FROM `user` ORDER BY MATCH(`title`) AGAINST('gorm' IN NATURAL LANGUAGE MODE) DESC, id,`name` DESC", sql)
+	})
+
+	t.Run("twice", func(t *testing.T) {
+		sql := db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+			return tx.Order("id").Clauses(Order(Search{Title: ptr("a")})).Order("age").Clauses(Order(Search{Body: ptr("b")})).Find(&[]User{})
+		})
+		as.Equal("SELECT * FROM `user` ORDER BY id, MATCH(`title`) AGAINST('a' IN NATURAL LANGUAGE MODE) DESC, age, "+
+			"MATCH(`body`) AGAINST('b' IN BOOLEAN MODE) DESC", sql)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		sql := db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+			return tx.Clauses(Order(Search{Summary: ptr("orm")})).Find(&[]User{})
+		})
+		as.Equal("SELECT * FROM `user`", sql)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		err := db.Session(&gorm.Session{DryRun: true}).Clauses(Order(1)).Find(&[]User{}).Error
+		as.NotNil(err)
+	})
+}
+
+func TestHas(t *testing.T) {
+	as := assert.New(t)
+	db := newDB().Session(&gorm.Session{DryRun: true})
+
+	type Order struct {
+		ID         uint
+		CustomerID uint
+		Status     string
+	}
+	type Profile struct {
+		ID         uint
+		CustomerID uint
+		City       string
+	}
+	type Tag struct {
+		ID   uint
+		Name string
+	}
+	type Company struct {
+		ID   uint
+		Name string
+	}
+	type Comment struct {
+		ID        uint
+		OwnerID   uint
+		OwnerType string
+	}
+	type Customer struct {
+		ID        uint
+		CompanyID uint
+		Company   Company
+		Profile   Profile
+		Orders    []Order
+		Tags      []Tag     `gorm:"many2many:customer_tags"`
+		Comments  []Comment `gorm:"polymorphic:Owner"`
+	}
+	type OrderWhere struct {
+		Status *string `gorm:"column:status"`
+	}
+	testHas := func(query interface{}, check func(sql string, err error)) {
+		stmt := db.Where(Query(query)).Find(&[]Customer{}).Statement
+		check(stmt.SQL.String(), stmt.Error)
+	}
+
+	t.Run("has many", func(t *testing.T) {
+		testHas(struct {
+			Orders *OrderWhere `gorm:"query_expr:has"`
+		}{
+			Orders: &OrderWhere{Status: ptr("paid")},
+		}, func(sql string, err error) {
+			as.Nil(err)
+			as.Equal("SELECT * FROM `customers` WHERE EXISTS (SELECT 1 FROM `orders` `has_orders` WHERE `has_orders`.`customer_id` = `customers`.`id` AND `has_orders`.`status` = ?)", sql)
+		})
+	})
+
+	t.Run("has one", func(t *testing.T) {
+		testHas(struct {
+			Profile struct {
+				City *string `gorm:"column:city"`
+			} `gorm:"query_expr:has"`
+		}{}, func(sql string, err error) {
+			as.Nil(err)
+			as.Equal("SELECT * FROM `customers` WHERE EXISTS (SELECT 1 FROM `profiles` `has_profile` WHERE `has_profile`.`customer_id` = `customers`.`id`)", sql)
+		})
+	})
+
+	t.Run("belongs to", func(t *testing.T) {
+		testHas(struct {
+			Company *struct {
+				Name *string `gorm:"column:name"`
+			} `gorm:"query_expr:has; association:Company"`
+		}{Company: &struct {
+			Name *string `gorm:"column:name"`
+		}{Name: ptr("gorm")}}, func(sql string, err error) {
+			as.Nil(err)
+			as.Equal("SELECT * FROM `customers` WHERE EXISTS (SELECT 1 FROM `companies` `has_company` WHERE `has_company`.`id` = `customers`.`company_id` AND `has_company`.`name` = ?)", sql)
+		})
+	})
+
+	t.Run("many2many", func(t *testing.T) {
+		testHas(struct {
+			Tags *struct {
+				Name []string `gorm:"column:name; query_expr:in"`
+			} `gorm:"query_expr:has"`
+		}{Tags: &struct {
+			Name []string `gorm:"column:name; query_expr:in"`
+		}{Name: []string{"vip", "new"}}}, func(sql string, err error) {
+			as.Nil(err)
+			as.Equal("SELECT * FROM `customers` WHERE EXISTS (SELECT 1 FROM `tags` `has_tags` WHERE "+
+				"EXISTS (SELECT 1 FROM `customer_tags` `has_tags_join` WHERE (`has_tags_join`.`customer_id` = `customers`.`id` AND `has_tags_join`.`tag_id` = `has_tags`.`id`)) "+
+				"AND `has_tags`.`name` IN (?,?))", sql)
+		})
+	})
+
+	t.Run("polymorphic", func(t *testing.T) {
+		testHas(struct {
+			Comments struct{} `gorm:"query_expr:has"`
+		}{}, func(sql string, err error) {
+			as.Nil(err)
+			as.Equal("SELECT * FROM `customers` WHERE EXISTS (SELECT 1 FROM `comments` `has_comments` WHERE `has_comments`.`owner_type` = ? AND `has_comments`.`owner_id` = `customers`.`id`)", sql)
+		})
+	})
+
+	t.Run("self-referential", func(t *testing.T) {
+		type Node struct {
+			ID       uint
+			ParentID *uint
+			Name     string
+			Children []Node `gorm:"foreignKey:ParentID"`
+		}
+		type NodeWhere struct {
+			Name *string `gorm:"column:name"`
+			Or   []struct {
+				ID *uint `gorm:"column:id; query_expr:>"`
+			} `gorm:"query_expr:or"`
+		}
+		query := struct {
+			Children   *NodeWhere `gorm:"query_expr:has"`
+			ChildCount *int       `gorm:"query_expr:has_count; association:Children"`
+		}{
+			Children: &NodeWhere{Name: ptr("leaf"), Or: []struct {
+				ID *uint `gorm:"column:id; query_expr:>"`
+			}{{ID: ptr[uint](1)}}},
+			ChildCount: ptr(2),
+		}
+		stmt := db.Where(Query(query)).Find(&[]Node{}).Statement
+		as.Nil(stmt.Error)
+		as.Equal("SELECT * FROM `nodes` WHERE (EXISTS (SELECT 1 FROM `nodes` `has_children` WHERE `has_children`.`parent_id` = `nodes`.`id` AND "+
+			"(`has_children`.`name` = ? AND `has_children`.`id` > ?)) AND "+
+			"(SELECT COUNT(*) FROM `nodes` `has_children` WHERE `has_children`.`parent_id` = `nodes`.`id`) = ?)", stmt.SQL.String())
+	})
+
+	t.Run("has_count", func(t *testing.T) {
+		testHas(struct {
+			OrderCount Range[int] `gorm:"query_expr:has_count; association:Orders"`
+			TagCount   *int       `gorm:"query_expr:has_count; association:Tags"`
+		}{
+			OrderCount: Range[int]{From: ptr(3)},
+			TagCount:   ptr(0),
+		}, func(sql string, err error) {
+			as.Nil(err)
+			as.Equal("SELECT * FROM `customers` WHERE ((SELECT COUNT(*) FROM `orders` `has_orders` WHERE `has_orders`.`customer_id` = `customers`.`id`) >= ? AND "+
+				"(SELECT COUNT(*) FROM `tags` `has_tags` WHERE EXISTS (SELECT 1 FROM `customer_tags` `has_tags_join` WHERE (`has_tags_join`.`customer_id` = `customers`.`id` AND `has_tags_join`.`tag_id` = `has_tags`.`id`))) = ?)", sql)
+		})
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		testHas(struct {
+			Invoices struct{} `gorm:"query_expr:has"`
+		}{}, func(sql string, err error) {
+			as.NotNil(err)
+			as.Equal("association(Invoices) not found in Customer", err.Error())
+		})
+
+		testHas(struct {
+			Orders string `gorm:"query_expr:has_count"`
+		}{Orders: "1"}, func(sql string, err error) {
+			as.NotNil(err)
+			as.Equal("struct field(Orders) with has_count query_expr must be integer or gormx.Range", err.Error())
+		})
+
+		err := db.Table("customers").Where(Query(struct {
+			Orders struct{} `gorm:"query_expr:has"`
+		}{})).Find(&[]map[string]interface{}{}).Error
+		as.NotNil(err)
+		as.Equal("association(Orders) need the model of statement", err.Error())
+	})
+}